@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"urlshortener/cmd"
+	"urlshortener/internal/repository"
+	"urlshortener/internal/services"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// Flags des commandes apikey
+var (
+	apiKeyNameFlag   string
+	apiKeyScopesFlag string
+	apiKeyIDFlag     uint
+)
+
+// APIKeyCmd représente la commande 'apikey'
+var APIKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Gère les clés d'accès à l'API.",
+	Long: `Cette commande permet de créer, lister et révoquer les clés API exigées par /api/v1.
+Chaque clé ne voit et ne modifie que les liens qu'elle a créés.
+
+Permissions disponibles : ` + strings.Join(services.AllScopes, ", ") + `.
+
+Exemples:
+  url-shortener apikey create --name="site vitrine" --scopes=links:write,stats:read
+  url-shortener apikey list
+  url-shortener apikey revoke --id=3`,
+}
+
+// APIKeyCreateCmd crée une nouvelle clé API.
+var APIKeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Crée une clé API et l'affiche une seule fois.",
+	Args:  cobra.NoArgs,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		scopes, err := services.ParseScopes(apiKeyScopesFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: --scopes: %v\n", err)
+			os.Exit(1)
+		}
+
+		apiKeyService, closeDB := openAPIKeyService()
+		defer closeDB()
+
+		key, rawKey, err := apiKeyService.CreateKey(apiKeyNameFlag, scopes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Clé API créée (id %d, permissions : %s).\n", key.ID, key.Scopes)
+		fmt.Printf("Clé : %s\n", rawKey)
+		fmt.Println("Conservez-la maintenant : elle ne pourra plus être affichée.")
+	},
+}
+
+// APIKeyListCmd liste les clés API.
+var APIKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les clés API, révoquées comprises.",
+	Args:  cobra.NoArgs,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		apiKeyService, closeDB := openAPIKeyService()
+		defer closeDB()
+
+		keys, err := apiKeyService.ListKeys()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("%-5s %-20s %-14s %-36s %-19s %s\n", "ID", "NOM", "PRÉFIXE", "PERMISSIONS", "DERNIER USAGE", "ÉTAT")
+		for _, key := range keys {
+			lastUsed := "jamais"
+			if key.LastUsedAt != nil {
+				lastUsed = key.LastUsedAt.Format(time.DateTime)
+			}
+			state := "active"
+			if key.RevokedAt != nil {
+				state = "révoquée le " + key.RevokedAt.Format(time.DateTime)
+			}
+			fmt.Printf("%-5d %-20s %-14s %-36s %-19s %s\n", key.ID, key.Name, key.Prefix+"…", key.Scopes, lastUsed, state)
+		}
+	},
+}
+
+// APIKeyRevokeCmd révoque une clé API.
+var APIKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Révoque une clé API.",
+	Args:  cobra.NoArgs,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		apiKeyService, closeDB := openAPIKeyService()
+		defer closeDB()
+
+		if err := apiKeyService.RevokeKey(apiKeyIDFlag); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Fprintln(os.Stderr, "Erreur: aucune clé active avec cet identifiant.")
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Clé API %d révoquée.\n", apiKeyIDFlag)
+	},
+}
+
+// openAPIKeyService ouvre la base de données configurée et prépare le APIKeyService.
+// La fonction retournée ferme la connexion.
+func openAPIKeyService() (*services.APIKeyService, func()) {
+	db, sqlDB := openDatabase(cmd.Cfg)
+	return services.NewAPIKeyService(repository.NewAPIKeyRepository(db)), func() { sqlDB.Close() }
+}
+
+func init() {
+	APIKeyCreateCmd.Flags().StringVar(&apiKeyNameFlag, "name", "", "Libellé de la clé")
+	APIKeyCreateCmd.Flags().StringVar(&apiKeyScopesFlag, "scopes", "", "Permissions séparées par des virgules (toutes par défaut)")
+	APIKeyCreateCmd.MarkFlagRequired("name")
+
+	APIKeyRevokeCmd.Flags().UintVar(&apiKeyIDFlag, "id", 0, "Identifiant de la clé à révoquer")
+	APIKeyRevokeCmd.MarkFlagRequired("id")
+
+	APIKeyCmd.AddCommand(APIKeyCreateCmd, APIKeyListCmd, APIKeyRevokeCmd)
+	cmd.RootCmd.AddCommand(APIKeyCmd)
+}
@@ -2,30 +2,55 @@ package cli
 
 import (
 	"fmt"
-	"log"
 	"net/url"
 	"os"
+	"time"
 
 	"urlshortener/cmd"
 	"urlshortener/internal/repository"
 	"urlshortener/internal/services"
 
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 // TODO : Faire une variable longURLFlag qui stockera la valeur du flag --url
 var longURLFlag string
 
+// aliasFlag stocke la valeur optionnelle du flag --alias
+var aliasFlag string
+
+// Flags optionnels de durée de vie du lien
+var (
+	expiresAtFlag string        // Date d'expiration au format RFC 3339
+	expiresInFlag time.Duration // Durée de vie relative (ex: 72h)
+	maxClicksFlag int           // Nombre maximum de clics (0 = illimité)
+)
+
+// tagsFlag stocke les étiquettes optionnelles du flag --tags
+var tagsFlag []string
+
+// fallbackURLFlag stocke l'URL de repli optionnelle du flag --fallback-url
+var fallbackURLFlag string
+
 // CreateCmd représente la commande 'create'
 var CreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Crée une URL courte à partir d'une URL longue.",
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
-Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+Un alias personnalisé peut être fourni avec --alias à la place du code généré.
+La durée de vie du lien peut être limitée avec --expires-at ou --expires-in, et son
+nombre de redirections avec --max-clicks. Des étiquettes, utilisées pour router les
+notifications du moniteur, peuvent être ajoutées avec --tags. Avec --fallback-url, les
+visiteurs sont redirigés vers une URL de repli quand le moniteur constate que l'URL
+longue est inaccessible.
+
+Exemples:
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://example.com/promo" --alias="spring-sale"
+  url-shortener create --url="https://example.com/promo" --expires-in=72h --max-clicks=100
+  url-shortener create --url="https://example.com/shop" --tags=boutique,critique
+  url-shortener create --url="https://example.com/promo" --fallback-url="https://example.com"`,
 	Run: func(cmdCobra *cobra.Command, args []string) {
 		// TODO 1: Valider que le flag --url a été fourni.
 		if longURLFlag == "" {
@@ -39,20 +64,40 @@ Exemple:
 			fmt.Fprintln(os.Stderr, "Erreur: URL invalide.")
 			os.Exit(1)
 		}
+		if fallbackURLFlag != "" {
+			if _, err := url.ParseRequestURI(fallbackURLFlag); err != nil {
+				fmt.Fprintln(os.Stderr, "Erreur: URL de repli invalide.")
+				os.Exit(1)
+			}
+		}
 
-		// TODO : Charger la configuration chargée globalement via cmd.cfg
-		cfg := cmd.Cfg
+		opts := services.LinkOptions{Alias: aliasFlag, Tags: tagsFlag, FallbackURL: fallbackURLFlag}
 
-		// TODO : Initialiser la connexion à la base de données SQLite.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+		if expiresAtFlag != "" && expiresInFlag != 0 {
+			fmt.Fprintln(os.Stderr, "Erreur: les flags --expires-at et --expires-in sont mutuellement exclusifs.")
+			os.Exit(1)
 		}
-
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+		if expiresAtFlag != "" {
+			expiresAt, err := time.Parse(time.RFC3339, expiresAtFlag)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Erreur: --expires-at doit être au format RFC 3339 (ex: 2025-12-31T23:59:00Z).")
+				os.Exit(1)
+			}
+			opts.ExpiresAt = &expiresAt
+		}
+		if expiresInFlag != 0 {
+			expiresAt := time.Now().Add(expiresInFlag)
+			opts.ExpiresAt = &expiresAt
+		}
+		if cmdCobra.Flags().Changed("max-clicks") {
+			opts.MaxClicks = &maxClicksFlag
 		}
+
+		// TODO : Charger la configuration chargée globalement via cmd.cfg
+		cfg := cmd.Cfg
+
+		// TODO : Initialiser la connexion à la base de données.
+		db, sqlDB := openDatabase(cfg)
 		defer sqlDB.Close()
 
 		// TODO : Initialiser les repositories et services nécessaires NewLinkRepository & NewLinkService
@@ -60,7 +105,7 @@ Exemple:
 		linkService := services.NewLinkService(linkRepo)
 
 		// TODO : Appeler le LinkService et la fonction CreateLink pour créer le lien court.
-		link, err := linkService.CreateLink(longURLFlag)
+		link, err := linkService.CreateLink(longURLFlag, opts)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
 			os.Exit(1)
@@ -70,6 +115,15 @@ Exemple:
 		fmt.Printf("URL courte créée avec succès:\n")
 		fmt.Printf("Code: %s\n", link.ShortCode)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks != nil {
+			fmt.Printf("Clics maximum: %d\n", *link.MaxClicks)
+		}
+		if link.FallbackURL != "" {
+			fmt.Printf("URL de repli: %s\n", link.FallbackURL)
+		}
 	},
 }
 
@@ -78,6 +132,12 @@ Exemple:
 func init() {
 	// TODO : Définir le flag --url pour la commande create.
 	CreateCmd.Flags().StringVar(&longURLFlag, "url", "", "URL longue à raccourcir")
+	CreateCmd.Flags().StringVar(&aliasFlag, "alias", "", "Alias personnalisé à utiliser comme code court (optionnel)")
+	CreateCmd.Flags().StringVar(&expiresAtFlag, "expires-at", "", "Date d'expiration au format RFC 3339 (optionnel)")
+	CreateCmd.Flags().DurationVar(&expiresInFlag, "expires-in", 0, "Durée de vie du lien, ex: 24h (optionnel)")
+	CreateCmd.Flags().IntVar(&maxClicksFlag, "max-clicks", 0, "Nombre maximum de clics avant expiration (optionnel)")
+	CreateCmd.Flags().StringSliceVar(&tagsFlag, "tags", nil, "Étiquettes séparées par des virgules (optionnel)")
+	CreateCmd.Flags().StringVar(&fallbackURLFlag, "fallback-url", "", "URL servie quand l'URL longue est inaccessible (optionnel)")
 
 	// TODO :  Marquer le flag comme requis
 	CreateCmd.MarkFlagRequired("url")
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"database/sql"
+
+	"urlshortener/internal/config"
+	"urlshortener/internal/database"
+	"urlshortener/internal/logging"
+
+	"gorm.io/gorm"
+)
+
+// openDatabase ouvre la connexion GORM configurée pour les commandes CLI.
+// Le programme s'arrête en cas d'échec ; l'appelant doit fermer la connexion SQL retournée.
+func openDatabase(cfg *config.Config) (*gorm.DB, *sql.DB) {
+	db, err := database.Open(cfg)
+	if err != nil {
+		logging.Fatal("Échec de la connexion à la base de données", "error", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		logging.Fatal("Échec de l'obtention de la base de données SQL sous-jacente", "error", err)
+	}
+	return db, sqlDB
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"urlshortener/cmd"
+	"urlshortener/internal/repository"
+	"urlshortener/internal/services"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// Flags de la commande delete
+var (
+	deleteCodeFlag    string
+	deleteRestoreFlag bool
+)
+
+// DeleteCmd représente la commande 'delete'
+var DeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Supprime (ou restaure) un lien court.",
+	Long: `Cette commande supprime logiquement un lien court : il n'est plus redirigé
+ni listé, mais ses statistiques sont conservées. Avec --restore, un lien
+précédemment supprimé est réactivé.
+
+Exemples:
+  url-shortener delete --code="xyz123"
+  url-shortener delete --code="xyz123" --restore`,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		cfg := cmd.Cfg
+		db, sqlDB := openDatabase(cfg)
+		defer sqlDB.Close()
+
+		linkService := services.NewLinkService(repository.NewLinkRepository(db))
+
+		if deleteRestoreFlag {
+			link, err := linkService.RestoreLink(deleteCodeFlag, nil)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					fmt.Fprintln(os.Stderr, "Erreur: aucun lien supprimé trouvé avec ce code.")
+					os.Exit(1)
+				}
+				fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Lien %s restauré.\n", link.ShortCode)
+			return
+		}
+
+		if err := linkService.DeleteLink(deleteCodeFlag, nil); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Fprintln(os.Stderr, "Erreur: aucun lien trouvé avec ce code.")
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Lien %s supprimé.\n", deleteCodeFlag)
+	},
+}
+
+func init() {
+	DeleteCmd.Flags().StringVar(&deleteCodeFlag, "code", "", "Code court du lien")
+	DeleteCmd.Flags().BoolVar(&deleteRestoreFlag, "restore", false, "Restaure un lien supprimé au lieu de le supprimer")
+	DeleteCmd.MarkFlagRequired("code")
+
+	cmd.RootCmd.AddCommand(DeleteCmd)
+}
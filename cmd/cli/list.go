@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"urlshortener/cmd"
+	"urlshortener/internal/repository"
+	"urlshortener/internal/services"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags de la commande list
+var (
+	listPageFlag          int
+	listPageSizeFlag      int
+	listCreatedAfterFlag  string
+	listCreatedBeforeFlag string
+	listURLContainsFlag   string
+)
+
+// ListCmd représente la commande 'list'
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les liens courts existants.",
+	Long: `Cette commande affiche les liens courts, du plus récent au plus ancien,
+avec pagination et filtres optionnels sur la date de création et l'URL longue.
+
+Exemples:
+  url-shortener list
+  url-shortener list --page=2 --page-size=50
+  url-shortener list --created-after=2025-01-01 --url-contains=example.com`,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		if listPageFlag < 1 || listPageSizeFlag < 1 {
+			fmt.Fprintln(os.Stderr, "Erreur: --page et --page-size doivent être strictement positifs.")
+			os.Exit(1)
+		}
+
+		filter := repository.LinkFilter{
+			URLContains: listURLContainsFlag,
+			Offset:      (listPageFlag - 1) * listPageSizeFlag,
+			Limit:       listPageSizeFlag,
+		}
+		var err error
+		if filter.CreatedAfter, err = services.ParseDate(listCreatedAfterFlag, time.UTC); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: --created-after: %v\n", err)
+			os.Exit(1)
+		}
+		if filter.CreatedBefore, err = services.ParseDate(listCreatedBeforeFlag, time.UTC); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: --created-before: %v\n", err)
+			os.Exit(1)
+		}
+
+		cfg := cmd.Cfg
+		db, sqlDB := openDatabase(cfg)
+		defer sqlDB.Close()
+
+		linkService := services.NewLinkService(repository.NewLinkRepository(db))
+
+		links, total, err := linkService.ListLinks(filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de la récupération des liens: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("%d lien(s) au total, page %d:\n", total, listPageFlag)
+		for _, link := range links {
+			fmt.Printf("%-12s %s  %s\n", link.ShortCode, link.CreatedAt.Format(time.DateTime), link.LongURL)
+		}
+	},
+}
+
+func init() {
+	ListCmd.Flags().IntVar(&listPageFlag, "page", 1, "Numéro de page")
+	ListCmd.Flags().IntVar(&listPageSizeFlag, "page-size", 20, "Nombre de liens par page")
+	ListCmd.Flags().StringVar(&listCreatedAfterFlag, "created-after", "", "Liens créés à partir de cette date")
+	ListCmd.Flags().StringVar(&listCreatedBeforeFlag, "created-before", "", "Liens créés avant cette date")
+	ListCmd.Flags().StringVar(&listURLContainsFlag, "url-contains", "", "Filtre sur une partie de l'URL longue")
+
+	cmd.RootCmd.AddCommand(ListCmd)
+}
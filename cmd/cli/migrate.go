@@ -2,54 +2,161 @@ package cli
 
 import (
 	"fmt"
-	"log"
+	"os"
+	"strconv"
+	"time"
 
 	"urlshortener/cmd"
-	"urlshortener/internal/models"
+	"urlshortener/internal/logging"
+	"urlshortener/internal/migrations"
 
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
+// migrateDirFlag est le répertoire des fichiers de migration utilisé par 'migrate create'.
+var migrateDirFlag string
+
 // MigrateCmd représente la commande 'migrate'
 var MigrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Exécute les migrations de la base de données pour créer ou mettre à jour les tables.",
-	Long: `Cette commande se connecte à la base de données configurée (SQLite)
-et exécute les migrations automatiques de GORM pour créer les tables 'links' et 'clicks'
-basées sur les modèles Go.`,
-	Run: func(_ *cobra.Command, args []string) {
-		// TODO : Charger la configuration chargée globalement via cmd.cfg
-		cfg := cmd.Cfg
-
-		// TODO 2: Initialiser la connexion à la base de données SQLite avec GORM.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+	Long: `Cette commande se connecte à la base de données configurée (SQLite, PostgreSQL ou MySQL)
+et applique les migrations versionnées embarquées dans le binaire qui ne l'ont pas encore été.
+Les migrations appliquées sont enregistrées dans la table 'schema_migrations'.
+
+Sans sous-commande, 'migrate' est équivalent à 'migrate up'.
+
+Exemples:
+  url-shortener migrate up
+  url-shortener migrate down 1
+  url-shortener migrate status
+  url-shortener migrate create add_link_tags`,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		runMigrateUp()
+	},
+}
+
+// MigrateUpCmd applique toutes les migrations en attente.
+var MigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Applique toutes les migrations en attente.",
+	Args:  cobra.NoArgs,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		runMigrateUp()
+	},
+}
+
+// MigrateDownCmd annule les N dernières migrations appliquées.
+var MigrateDownCmd = &cobra.Command{
+	Use:   "down N",
+	Short: "Annule les N dernières migrations appliquées.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			fmt.Fprintln(os.Stderr, "Erreur: N doit être un entier supérieur ou égal à 1.")
+			os.Exit(1)
 		}
 
-		sqlDB, err := db.DB()
+		migrator, closeDB := openMigrator()
+		defer closeDB()
+
+		reverted, err := migrator.Down(n)
+		for _, m := range reverted {
+			fmt.Printf("Annulée : %04d_%s\n", m.Version, m.Name)
+		}
 		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			logging.Fatal("Erreur lors de l'annulation des migrations", "error", err)
 		}
+		if len(reverted) < n {
+			fmt.Printf("Seulement %d migration(s) appliquée(s) à annuler.\n", len(reverted))
+		}
+	},
+}
 
-		// TODO Assurez-vous que la connexion est fermée après la migration.
-		defer sqlDB.Close()
+// MigrateStatusCmd affiche l'état de chaque migration.
+var MigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Affiche les migrations appliquées et en attente.",
+	Args:  cobra.NoArgs,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		migrator, closeDB := openMigrator()
+		defer closeDB()
 
-		// TODO 3: Exécuter les migrations automatiques de GORM.
-		// Utilisez db.AutoMigrate() et passez-lui les pointeurs vers tous vos modèles.
-		err = db.AutoMigrate(&models.Link{}, &models.Click{})
+		statuses, err := migrator.Status()
 		if err != nil {
-			log.Fatalf("FATAL: Erreur lors de l'exécution des migrations: %v", err)
+			logging.Fatal("Erreur lors de la lecture de l'état des migrations", "error", err)
 		}
 
-		// Pas touche au log
-		fmt.Println("Migrations de la base de données exécutées avec succès.")
+		fmt.Printf("%-8s %-40s %s\n", "VERSION", "NOM", "ÉTAT")
+		for _, s := range statuses {
+			state := "en attente"
+			if s.AppliedAt != nil {
+				state = "appliquée le " + s.AppliedAt.Format(time.DateTime)
+			}
+			if s.Missing {
+				state += " (absente de ce binaire)"
+			}
+			fmt.Printf("%-8s %-40s %s\n", fmt.Sprintf("%04d", s.Version), s.Name, state)
+		}
 	},
 }
 
+// MigrateCreateCmd crée les fichiers d'une nouvelle migration pour chaque pilote.
+var MigrateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Crée les fichiers SQL (up et down) d'une nouvelle migration pour chaque pilote.",
+	Long: `Cette commande crée, dans le répertoire des migrations du dépôt, une paire de fichiers
+<version>_<name>.up.sql et .down.sql pour chaque pilote supporté. Les migrations étant embarquées
+dans le binaire, il faut le reconstruire avant de les appliquer.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		created, err := migrations.Create(migrateDirFlag, args[0])
+		for _, file := range created {
+			fmt.Printf("Créé : %s\n", file)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// runMigrateUp applique les migrations en attente.
+func runMigrateUp() {
+	migrator, closeDB := openMigrator()
+	defer closeDB()
+
+	applied, err := migrator.Up()
+	for _, m := range applied {
+		fmt.Printf("Appliquée : %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		logging.Fatal("Erreur lors de l'exécution des migrations", "error", err)
+	}
+
+	// Pas touche au log
+	fmt.Println("Migrations de la base de données exécutées avec succès.")
+}
+
+// openMigrator ouvre la base de données configurée et prépare le Migrator.
+// La fonction retournée ferme la connexion.
+func openMigrator() (*migrations.Migrator, func()) {
+	db, sqlDB := openDatabase(cmd.Cfg)
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		sqlDB.Close()
+		logging.Fatal("Erreur lors de la préparation des migrations", "error", err)
+	}
+	return migrator, func() { sqlDB.Close() }
+}
+
 func init() {
+	MigrateCreateCmd.Flags().StringVar(&migrateDirFlag, "dir", migrations.SourceDir, "Répertoire des fichiers de migration")
+
+	MigrateCmd.AddCommand(MigrateUpCmd, MigrateDownCmd, MigrateStatusCmd, MigrateCreateCmd)
+
 	// TODO : Ajouter la commande à RootCmd
 	cmd.RootCmd.AddCommand(MigrateCmd)
 }
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"urlshortener/cmd"
+	"urlshortener/internal/repository"
+	"urlshortener/internal/services"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags de la commande prune-clicks
+var (
+	pruneDryRunFlag bool
+	pruneDaysFlag   int
+	pruneModeFlag   string
+)
+
+// PruneClicksCmd représente la commande 'prune-clicks'
+var PruneClicksCmd = &cobra.Command{
+	Use:   "prune-clicks",
+	Short: "Applique la politique de rétention des clics.",
+	Long: `Cette commande supprime (ou agrège en totaux journaliers) les clics plus anciens
+que la durée de conservation configurée dans 'retention'. Il s'agit de la même
+politique que celle appliquée périodiquement par le serveur.
+
+--days et --mode remplacent ponctuellement la configuration ; --dry-run affiche
+le nombre de clics concernés sans rien modifier.
+
+Exemples:
+  url-shortener prune-clicks --dry-run
+  url-shortener prune-clicks --days=90 --mode=aggregate`,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		cfg := cmd.Cfg
+
+		policy := services.RetentionPolicy{
+			Days: cfg.Retention.ClickDays,
+			Mode: services.RetentionMode(cfg.Retention.Mode),
+		}
+		if cmdCobra.Flags().Changed("days") {
+			policy.Days = pruneDaysFlag
+		}
+		if cmdCobra.Flags().Changed("mode") {
+			policy.Mode = services.RetentionMode(pruneModeFlag)
+		}
+
+		db, sqlDB := openDatabase(cfg)
+		defer sqlDB.Close()
+
+		retentionService, err := services.NewRetentionService(repository.NewClickRepository(db), policy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		if !retentionService.Enabled() {
+			fmt.Fprintln(os.Stderr, "Erreur: aucune durée de conservation configurée (retention.click_days ou --days).")
+			os.Exit(1)
+		}
+
+		result, err := retentionService.Prune(pruneDryRunFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			os.Exit(1)
+		}
+
+		cutoff := result.Cutoff.Format(time.DateTime)
+		if result.DryRun {
+			fmt.Printf("[dry-run] %d clic(s) antérieur(s) au %s seraient traités (mode %s).\n", result.Clicks, cutoff, result.Mode)
+			return
+		}
+		fmt.Printf("%d clic(s) antérieur(s) au %s traité(s) (mode %s).\n", result.Clicks, cutoff, result.Mode)
+	},
+}
+
+func init() {
+	PruneClicksCmd.Flags().BoolVar(&pruneDryRunFlag, "dry-run", false, "Affiche le nombre de clics concernés sans les modifier")
+	PruneClicksCmd.Flags().IntVar(&pruneDaysFlag, "days", 0, "Durée de conservation en jours (remplace retention.click_days)")
+	PruneClicksCmd.Flags().StringVar(&pruneModeFlag, "mode", "", "delete ou aggregate (remplace retention.mode)")
+
+	cmd.RootCmd.AddCommand(PruneClicksCmd)
+}
@@ -1,22 +1,34 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"os"
+	"strings"
+	"time"
 
 	cmd "urlshortener/cmd"
+	"urlshortener/internal/models"
 	"urlshortener/internal/repository"
 	"urlshortener/internal/services"
 
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
 	"gorm.io/gorm"
 )
 
 // TODO : variable shortCodeFlag qui stockera la valeur du flag --code
 var shortCodeFlag string
 
+// Flags optionnels de la série temporelle de clics
+var (
+	statsFromFlag     string
+	statsToFlag       string
+	statsIntervalFlag string
+	statsTimezoneFlag string
+	statsTopFlag      int
+	statsIncludeBots  bool
+)
+
 // StatsCmd représente la commande 'stats'
 var StatsCmd = &cobra.Command{
 	Use:   "stats",
@@ -24,8 +36,20 @@ var StatsCmd = &cobra.Command{
 	Long: `Cette commande permet de récupérer et d'afficher le nombre total de clics
 pour une URL courte spécifique en utilisant son code.
 
-Exemple:
-  url-shortener stats --code="xyz123"`,
+Les clics attribués à des robots sont exclus, sauf avec --include-bots.
+L'état de santé de l'URL longue (dernière vérification du moniteur et disponibilité
+sur 24 heures, 7 jours et 30 jours) est affiché avant les clics.
+
+Les répartitions par referrer, navigateur, système et appareil sont limitées
+aux --top valeurs les plus fréquentes.
+
+Les visiteurs uniques sont comptés sur la plage --from/--to (30 derniers jours par défaut).
+Avec --interval, --from ou --to, la commande affiche aussi les visiteurs uniques par jour
+et l'évolution des clics par heure, jour ou semaine.
+
+Exemples:
+  url-shortener stats --code="xyz123"
+  url-shortener stats --code="xyz123" --interval=day --from=2025-01-01 --to=2025-02-01 --timezone=Europe/Paris`,
 	Run: func(cmdCobra *cobra.Command, args []string) {
 		// TODO : Valider que le flag --code a été fourni.
 		if shortCodeFlag == "" {
@@ -36,16 +60,8 @@ Exemple:
 		// TODO : Charger la configuration chargée globalement via cmd.cfg
 		cfg := cmd.Cfg
 
-		// TODO 3: Initialiser la connexion à la base de données SQLite avec GORM.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
-		}
-
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
-		}
+		// TODO 3: Initialiser la connexion à la base de données avec GORM.
+		db, sqlDB := openDatabase(cfg)
 
 		// TODO S'assurer que la connexion est fermée à la fin de l'exécution de la commande
 		defer sqlDB.Close()
@@ -58,9 +74,10 @@ Exemple:
 		// Attention, la fonction retourne 3 valeurs
 		// Pour l'erreur, utilisez gorm.ErrRecordNotFound
 		// Si erreur, os.Exit(1)
-		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag)
+		filter := repository.ClickFilter{IncludeBots: statsIncludeBots}
+		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag, filter, nil)
 		if err != nil {
-			if err == gorm.ErrRecordNotFound {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
 				fmt.Fprintln(os.Stderr, "Erreur: aucun lien trouvé avec ce code.")
 				os.Exit(1)
 			}
@@ -71,14 +88,196 @@ Exemple:
 		fmt.Printf("Statistiques pour le code court: %s\n", link.ShortCode)
 		fmt.Printf("URL longue: %s\n", link.LongURL)
 		fmt.Printf("Total de clics: %d\n", totalClicks)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks != nil {
+			fmt.Printf("Clics maximum: %d\n", *link.MaxClicks)
+		}
+		if link.HasExpired(time.Now()) {
+			fmt.Println("Statut: EXPIRÉ")
+		}
+
+		printLinkHealth(services.NewHealthService(repository.NewCheckRepository(db)), link)
+
+		clickService := services.NewClickService(repository.NewClickRepository(db))
+		if link.FallbackURL != "" {
+			fmt.Printf("URL de repli: %s\n", link.FallbackURL)
+		}
+		if fallbackRedirects, err := clickService.GetFallbackRedirects(link.ID); err == nil && fallbackRedirects > 0 {
+			fmt.Printf("Redirections vers une URL de repli: %d\n", fallbackRedirects)
+		}
+		detailed := statsIntervalFlag != "" || statsFromFlag != "" || statsToFlag != ""
+
+		printUniqueVisitors(clickService, link.ID, filter, detailed)
+		printClickBreakdowns(clickService, link.ID, filter)
+
+		if !detailed {
+			return
+		}
+		printClickTimeSeries(clickService, link.ID, filter)
 	},
 }
 
+// printLinkHealth affiche le statut du lien selon le moniteur et sa disponibilité récente.
+func printLinkHealth(healthService *services.HealthService, link *models.Link) {
+	health, err := healthService.GetLinkHealth(link)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors de la récupération de l'état de santé: %v\n", err)
+		os.Exit(1)
+	}
+
+	if health.Status == services.HealthUnknown {
+		fmt.Println("Santé: jamais vérifié par le moniteur")
+		return
+	}
+	detail := ""
+	if health.StatusCode != 0 {
+		detail = fmt.Sprintf(" (HTTP %d)", health.StatusCode)
+	} else if health.ErrorClass != "" {
+		detail = fmt.Sprintf(" (%s)", health.ErrorClass)
+	}
+	fmt.Printf("Santé: %s%s depuis le %s, vérifié le %s\n", strings.ToUpper(health.Status), detail,
+		health.LastChangeAt.Format(time.DateTime), health.LastCheckedAt.Format(time.DateTime))
+	if health.FinalURL != "" && health.FinalURL != link.LongURL {
+		fmt.Printf("URL finale: %s\n", health.FinalURL)
+	}
+
+	fmt.Print("Disponibilité:")
+	for _, window := range []struct {
+		label  string
+		uptime services.UptimeWindow
+	}{{"24h", health.Uptime.Day}, {"7j", health.Uptime.Week}, {"30j", health.Uptime.Month}} {
+		if window.uptime.Percent == nil {
+			fmt.Printf("  %s -", window.label)
+			continue
+		}
+		fmt.Printf("  %s %.2f%%", window.label, *window.uptime.Percent)
+	}
+	fmt.Println()
+}
+
+// printClickBreakdowns affiche les valeurs les plus fréquentes de chaque répartition des clics.
+func printClickBreakdowns(clickService *services.ClickService, linkID uint, filter repository.ClickFilter) {
+	breakdowns, err := clickService.GetClickBreakdowns(linkID, statsTopFlag, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors de la récupération des répartitions: %v\n", err)
+		os.Exit(1)
+	}
+
+	sections := []struct{ key, title string }{
+		{"referrers", "Referrers"},
+		{"browsers", "Navigateurs"},
+		{"os", "Systèmes d'exploitation"},
+		{"devices", "Appareils"},
+	}
+	for _, section := range sections {
+		entries := breakdowns[section.key]
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", section.title)
+		for _, entry := range entries {
+			fmt.Printf("  %-30s %d\n", entry.Value, entry.Count)
+		}
+	}
+}
+
+// statsTimeRange lit les flags --from, --to et --timezone. Par défaut, la plage se termine
+// maintenant et couvre defaultSpan. Le programme s'arrête si un flag est invalide.
+func statsTimeRange(defaultSpan time.Duration) (time.Time, time.Time, *time.Location) {
+	loc, err := time.LoadLocation(statsTimezoneFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: --timezone: fuseau horaire inconnu %q\n", statsTimezoneFlag)
+		os.Exit(1)
+	}
+
+	to := time.Now()
+	if t, err := services.ParseDate(statsToFlag, loc); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: --to: %v\n", err)
+		os.Exit(1)
+	} else if t != nil {
+		to = *t
+	}
+	from := to.Add(-defaultSpan)
+	if t, err := services.ParseDate(statsFromFlag, loc); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: --from: %v\n", err)
+		os.Exit(1)
+	} else if t != nil {
+		from = *t
+	}
+	return from, to, loc
+}
+
+// printUniqueVisitors affiche le nombre de visiteurs uniques sur la plage --from/--to
+// (30 derniers jours par défaut), avec le détail par jour UTC si 'daily' est vrai : les empreintes
+// de visiteurs sont renouvelées chaque jour UTC, --timezone ne s'applique qu'aux dates --from/--to.
+func printUniqueVisitors(clickService *services.ClickService, linkID uint, filter repository.ClickFilter, daily bool) {
+	from, to, _ := statsTimeRange(services.IntervalDay.DefaultSpan())
+
+	visitors, err := clickService.GetUniqueVisitors(linkID, from, to, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors du calcul des visiteurs uniques: %v\n", err)
+		os.Exit(1)
+	}
+
+	approx := ""
+	if visitors.Approximate {
+		approx = "~"
+	}
+	fmt.Printf("Visiteurs uniques du %s au %s: %s%d\n",
+		from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly), approx, visitors.Total)
+
+	if !daily {
+		return
+	}
+	fmt.Printf("\nVisiteurs uniques par jour (UTC):\n")
+	for _, day := range visitors.Daily {
+		fmt.Printf("  %s  %d\n", day.Date, day.Count)
+	}
+}
+
+// printClickTimeSeries affiche le nombre de clics par période selon les flags --from, --to, --interval et --timezone.
+func printClickTimeSeries(clickService *services.ClickService, linkID uint, filter repository.ClickFilter) {
+	interval := services.IntervalDay
+	if statsIntervalFlag != "" {
+		var err error
+		if interval, err = services.ParseInterval(statsIntervalFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: --interval: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	from, to, loc := statsTimeRange(interval.DefaultSpan())
+
+	buckets, err := clickService.GetClickTimeSeries(linkID, from, to, interval, loc, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors de la récupération de la série temporelle: %v\n", err)
+		os.Exit(1)
+	}
+
+	layout := time.DateOnly
+	if interval == services.IntervalHour {
+		layout = "2006-01-02 15:00"
+	}
+
+	fmt.Printf("\nClics par %s (%s):\n", interval, loc)
+	for _, bucket := range buckets {
+		fmt.Printf("  %s  %d\n", bucket.Start.Format(layout), bucket.Count)
+	}
+}
+
 // init() s'exécute automatiquement lors de l'importation du package.
 // Il est utilisé pour définir les flags que cette commande accepte.
 func init() {
 	// TODO 7: Définir le flag --code pour la commande stats.
 	StatsCmd.Flags().StringVar(&shortCodeFlag, "code", "", "Code court de l'URL")
+	StatsCmd.Flags().StringVar(&statsFromFlag, "from", "", "Début de la série temporelle (RFC 3339 ou AAAA-MM-JJ)")
+	StatsCmd.Flags().StringVar(&statsToFlag, "to", "", "Fin de la série temporelle, exclue (RFC 3339 ou AAAA-MM-JJ)")
+	StatsCmd.Flags().StringVar(&statsIntervalFlag, "interval", "", "Granularité de la série temporelle: hour, day ou week")
+	StatsCmd.Flags().BoolVar(&statsIncludeBots, "include-bots", false, "Inclure les clics attribués à des robots")
+	StatsCmd.Flags().IntVar(&statsTopFlag, "top", 5, "Nombre de valeurs affichées par répartition")
+	StatsCmd.Flags().StringVar(&statsTimezoneFlag, "timezone", "UTC", "Fuseau horaire des périodes (nom IANA, ex: Europe/Paris)")
 
 	// TODO Marquer le flag comme requis
 	StatsCmd.MarkFlagRequired("code")
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"urlshortener/cmd"
+	"urlshortener/internal/repository"
+	"urlshortener/internal/services"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// Flags de la commande update
+var (
+	updateCodeFlag string
+	updateURLFlag  string
+)
+
+// UpdateCmd représente la commande 'update'
+var UpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Modifie l'URL de destination d'un lien court.",
+	Long: `Cette commande remplace l'URL longue associée à un code court existant.
+
+Exemple:
+  url-shortener update --code="xyz123" --url="https://example.com/nouvelle-page"`,
+	Run: func(cmdCobra *cobra.Command, args []string) {
+		if _, err := url.ParseRequestURI(updateURLFlag); err != nil {
+			fmt.Fprintln(os.Stderr, "Erreur: URL invalide.")
+			os.Exit(1)
+		}
+
+		cfg := cmd.Cfg
+		db, sqlDB := openDatabase(cfg)
+		defer sqlDB.Close()
+
+		linkService := services.NewLinkService(repository.NewLinkRepository(db))
+
+		link, err := linkService.UpdateLinkURL(updateCodeFlag, updateURLFlag, nil)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Fprintln(os.Stderr, "Erreur: aucun lien trouvé avec ce code.")
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Lien %s mis à jour: %s\n", link.ShortCode, link.LongURL)
+	},
+}
+
+func init() {
+	UpdateCmd.Flags().StringVar(&updateCodeFlag, "code", "", "Code court du lien à modifier")
+	UpdateCmd.Flags().StringVar(&updateURLFlag, "url", "", "Nouvelle URL longue")
+	UpdateCmd.MarkFlagRequired("code")
+	UpdateCmd.MarkFlagRequired("url")
+
+	cmd.RootCmd.AddCommand(UpdateCmd)
+}
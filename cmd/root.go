@@ -2,12 +2,14 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
 	"urlshortener/internal/config"
+	"urlshortener/internal/logging"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // cfg est la variable globale qui contiendra la configuration chargée.
@@ -69,12 +71,32 @@ func init() {
 func initConfig() {
 	var err error
 	Cfg, err = config.LoadConfig()
+
+	// La journalisation est configurée avant tout autre message, avec les valeurs par défaut
+	// si la configuration n'a pas pu être chargée ou si ses réglages sont invalides.
+	logFormat, logLevel := logging.FormatText, "info"
+	if Cfg != nil {
+		logFormat, logLevel = Cfg.Log.Format, Cfg.Log.Level
+	}
+	if logErr := logging.Setup(logFormat, logLevel); logErr != nil {
+		logging.Setup(logging.FormatText, "info")
+		slog.Warn("Configuration de la journalisation invalide, utilisation des valeurs par défaut", "error", logErr)
+	}
+
 	if err != nil {
-		// Loggue l'erreur mais ne fait pas un os.Exit(1) ici si LoadConfig()
-		// gère déjà l'absence de fichier avec des valeurs par défaut.
-		// Si LoadConfig() termine le programme en cas d'erreur fatale,
-		// cette vérification est surtout pour les avertissements.
-		log.Printf("Attention: Problème lors du chargement de la configuration: %v. Utilisation des valeurs par défaut.", err)
+		// L'absence de fichier est déjà gérée par LoadConfig avec les valeurs par défaut :
+		// une erreur ici est un fichier illisible ou une valeur invalide, sans laquelle
+		// les commandes ne peuvent pas s'exécuter.
+		logging.Fatal("Impossible de charger la configuration", "error", err)
+	}
+	if viper.ConfigFileUsed() == "" {
+		slog.Info("Aucun fichier de configuration trouvé, utilisation des valeurs par défaut")
 	}
+	slog.Info("Configuration chargée",
+		"server_port", Cfg.Server.Port,
+		"db_driver", Cfg.Database.Driver,
+		"db_name", Cfg.Database.Name,
+		"analytics_buffer", Cfg.Analytics.BufferSize,
+		"monitor_interval_minutes", Cfg.Monitor.IntervalMinutes)
 	// La configuration est maintenant disponible via la variable globale 'cmd.cfg'.
 }
@@ -1,8 +1,9 @@
 package server
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,16 +12,24 @@ import (
 
 	"urlshortener/cmd"
 	"urlshortener/internal/api"
+	"urlshortener/internal/bots"
+	"urlshortener/internal/clickqueue"
+	"urlshortener/internal/config"
+	"urlshortener/internal/database"
+	"urlshortener/internal/logging"
+	"urlshortener/internal/metrics"
 	"urlshortener/internal/models"
 	"urlshortener/internal/monitor"
+	"urlshortener/internal/notify"
+	"urlshortener/internal/privacy"
+	"urlshortener/internal/ratelimit"
 	"urlshortener/internal/repository"
 	"urlshortener/internal/services"
+	"urlshortener/internal/urlpolicy"
 	"urlshortener/internal/workers"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 // RunServerCmd représente la commande 'run-server' de Cobra.
@@ -35,47 +44,244 @@ puis lance le serveur HTTP.`,
 		// TODO : Charger la configuration chargée globalement via cmd.cfg
 		cfg := cmd.Cfg
 		if cfg == nil {
-			log.Fatalf("Erreur : configuration non chargée.")
+			logging.Fatal("Configuration non chargée")
 		}
 
-		// TODO : Initialiser la connexion à la base de données SQLite avec GORM.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		// TODO : Initialiser la connexion à la base de données avec GORM.
+		db, err := database.Open(cfg)
 		if err != nil {
-			log.Fatalf("Erreur de connexion DB : %v", err)
+			logging.Fatal("Erreur de connexion à la base de données", "error", err)
 		}
 
 		// TODO : Initialiser les repositories.
-		linkRepo := repository.NewLinkRepository(db)
+		var linkRepo repository.LinkRepository = repository.NewLinkRepository(db)
 		clickRepo := repository.NewClickRepository(db)
+		checkRepo := repository.NewCheckRepository(db)
+
+		// Cache mémoire des liens devant la base, pour servir les redirections sans requête SQL.
+		if cfg.Cache.Enabled {
+			linkCache := repository.NewCachedLinkRepository(linkRepo, repository.LinkCacheOptions{
+				Size:        cfg.Cache.Size,
+				TTL:         time.Duration(cfg.Cache.TTLSeconds) * time.Second,
+				NegativeTTL: time.Duration(cfg.Cache.NegativeTTLSeconds) * time.Second,
+			})
+			api.LinkCache = linkCache
+			linkRepo = linkCache
+			slog.Info("Cache des liens activé", "size", cfg.Cache.Size, "ttl_seconds", cfg.Cache.TTLSeconds)
+		}
 
 		// Laissez le log
-		log.Println("Repositories initialisés.")
+		slog.Info("Repositories initialisés")
+
+		// Contrôles des URLs de destination soumises à l'API.
+		urlPolicies := []services.URLPolicy{urlpolicy.NewSchemePolicy(cfg.URLPolicy.AllowedSchemes)}
+		var blocklist *urlpolicy.Blocklist
+		if cfg.URLPolicy.BlocklistFile != "" {
+			blocklist, err = urlpolicy.LoadBlocklist(cfg.URLPolicy.BlocklistFile)
+			if err != nil {
+				logging.Fatal("Erreur de chargement de la liste de domaines bloqués", "error", err)
+			}
+			blocklist.Start(time.Duration(cfg.URLPolicy.BlocklistReloadSeconds) * time.Second)
+			urlPolicies = append(urlPolicies, blocklist)
+			slog.Info("Liste de domaines bloqués chargée", "file", cfg.URLPolicy.BlocklistFile, "domains", blocklist.Len())
+		}
+		if cfg.URLPolicy.BlockPrivateAddresses {
+			urlPolicies = append(urlPolicies, urlpolicy.NewAddressPolicy(time.Duration(cfg.URLPolicy.DNSTimeoutMs)*time.Millisecond))
+		}
 
 		// TODO : Initialiser les services métiers.
-		linkService := services.NewLinkService(linkRepo)
-		// clickService := services.NewClickService(clickRepo)
+		linkService := services.NewLinkService(linkRepo, urlPolicies...)
+		clickService := services.NewClickService(clickRepo)
+		healthService := services.NewHealthService(checkRepo)
 
 		// Laissez le log
-		log.Println("Services métiers initialisés.")
+		slog.Info("Services métiers initialisés")
 
 		// TODO : Initialiser le channel ClickEventsChannel (api/handlers) des événements de clic et lancer les workers (StartClickWorkers).
+		botPatterns := bots.DefaultPatterns()
+		if cfg.Bots.PatternsFile != "" {
+			botPatterns, err = bots.LoadPatterns(cfg.Bots.PatternsFile)
+			if err != nil {
+				logging.Fatal("Erreur de chargement des motifs de robots", "error", err)
+			}
+		}
+		botClassifier := bots.NewClassifier(append(botPatterns, cfg.Bots.ExtraPatterns...))
+		api.BotClassifier = botClassifier
+		slog.Info("Détection des robots initialisée", "patterns", len(botPatterns)+len(cfg.Bots.ExtraPatterns))
+
+		if cfg.Privacy.VisitorSalt == "" {
+			slog.Warn("privacy.visitor_salt n'est pas configuré, un sel aléatoire est utilisé pour les empreintes de visiteurs")
+		}
+		visitorHasher, err := privacy.NewVisitorHasher(cfg.Privacy.VisitorSalt)
+		if err != nil {
+			logging.Fatal("Erreur d'initialisation des empreintes de visiteurs", "error", err)
+		}
+		clickEnricher := &workers.ClickEnricher{
+			BotClassifier:      botClassifier,
+			VisitorHasher:      visitorHasher,
+			DropRawIdentifiers: cfg.Privacy.DropRawIdentifiers,
+			AnonymizeIP:        cfg.Privacy.AnonymizeIP,
+		}
+
 		api.ClickEventsChannel = make(chan models.ClickEvent, cfg.Analytics.BufferSize)
-		workers.StartClickWorkers(cfg.Analytics.BufferSize, api.ClickEventsChannel, clickRepo)
+		clickBatch := workers.BatchOptions{
+			Size:          cfg.Analytics.BatchSize,
+			FlushInterval: time.Duration(cfg.Analytics.FlushIntervalMs) * time.Millisecond,
+		}
+
+		// File durable optionnelle : les redirections y écrivent, et son dispatcher alimente le channel.
+		if cfg.Analytics.Queue.Enabled {
+			clickQueue, err := clickqueue.Open(clickqueue.Options{
+				Dir:             cfg.Analytics.Queue.Dir,
+				SyncInterval:    time.Duration(cfg.Analytics.Queue.SyncIntervalMs) * time.Millisecond,
+				SegmentMaxBytes: cfg.Analytics.Queue.SegmentMaxBytes,
+			})
+			if err != nil {
+				logging.Fatal("Erreur d'ouverture de la file durable des clics", "error", err)
+			}
+			api.ClickQueue = clickQueue
+			clickBatch.OnPersisted = clickQueue.Ack
+			clickQueue.Start(api.ClickEventsChannel)
+			slog.Info("File durable des clics ouverte", "dir", cfg.Analytics.Queue.Dir)
+		}
+
+		clickWorkers := workers.NewClickWorkerPool(cfg.Analytics.WorkerCount, clickBatch, api.ClickEventsChannel, clickRepo, clickEnricher)
+		clickWorkers.Start()
 
 		// TODO : Remplacer les XXX par les bonnes variables
-		log.Printf("Channel d'événements de clic initialisé avec un buffer de %d. %d worker(s) de clics démarré(s).",
-			cfg.Analytics.BufferSize, 1)
+		slog.Info("Channel d'événements de clic initialisé, workers de clics démarrés",
+			"buffer_size", cfg.Analytics.BufferSize, "workers", cfg.Analytics.WorkerCount)
+
+		// Purge périodique des clics selon la politique de rétention.
+		retentionService, err := services.NewRetentionService(clickRepo, services.RetentionPolicy{
+			Days: cfg.Retention.ClickDays,
+			Mode: services.RetentionMode(cfg.Retention.Mode),
+		})
+		if err != nil {
+			logging.Fatal("Erreur de configuration de la rétention des clics", "error", err)
+		}
+		var retentionJob *workers.RetentionJob
+		if retentionService.Enabled() {
+			retentionJob = workers.NewRetentionJob(retentionService, time.Duration(cfg.Retention.IntervalHours)*time.Hour)
+			retentionJob.Start()
+		} else {
+			slog.Info("Rétention des clics désactivée (conservation illimitée)")
+		}
+
+		// Notifications des changements d'état des liens surveillés.
+		notifier, err := notify.NewDispatcherFromConfig(cfg)
+		if err != nil {
+			logging.Fatal("Erreur de configuration des notifications", "error", err)
+		}
+		if notifier != nil {
+			notifier.Start()
+			slog.Info("Notifications des changements d'état des liens activées")
+		}
 
 		// TODO : Initialiser et lancer le moniteur d'URLs.
 		monitorInterval := time.Duration(cfg.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval)
-		go urlMonitor.Start()
-		log.Printf("Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
+		urlMonitor := monitor.NewUrlMonitor(linkRepo, checkRepo, notifier, monitorInterval, monitor.ClientOptions{
+			Timeout:          time.Duration(cfg.Monitor.TimeoutSeconds) * time.Second,
+			MaxRedirects:     cfg.Monitor.MaxRedirects,
+			MaxResponseBytes: cfg.Monitor.MaxResponseBytes,
+			AllowPrivate:     cfg.Monitor.AllowPrivateAddresses,
+		}, monitor.CheckOptions{
+			Workers:            cfg.Monitor.WorkerCount,
+			PerHostConcurrency: cfg.Monitor.PerHostConcurrency,
+			RatePerSecond:      cfg.Monitor.ChecksPerSecond,
+			Jitter:             monitorInterval * time.Duration(cfg.Monitor.JitterPercent) / 100,
+			HistoryRetention:   time.Duration(cfg.Monitor.HistoryDays) * 24 * time.Hour,
+			ContentFingerprint: cfg.Monitor.ContentFingerprint,
+		})
+		urlMonitor.Start()
+		slog.Info("Moniteur d'URLs démarré", "interval", monitorInterval.String())
+
+		// Redirections vers l'URL de repli des liens dont l'URL longue est inaccessible.
+		fallbackService := services.NewFallbackService(urlMonitor, cfg.Fallback.DefaultURL, cfg.Fallback.AfterFailedChecks)
 
 		// TODO : Configurer le routeur Gin et les handlers API.
-		router := gin.Default()
-		api.SetupRoutes(router, linkService)
-		log.Println("Routes API configurées.")
+		// Logger et récupération des paniques de Gin remplacés par leurs équivalents slog, derrière
+		// l'identifiant de requête pour que chaque ligne journalisée le porte.
+		gin.SetMode(gin.ReleaseMode)
+		router := gin.New()
+		router.Use(api.RequestID(), api.AccessLog(), api.Recovery())
+		// L'adresse du client (limitation du débit, clics, journaux) n'est lue dans X-Forwarded-For
+		// que derrière un proxy de confiance : sinon n'importe quel client pourrait la choisir.
+		if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+			logging.Fatal("Configuration invalide de server.trusted_proxies", "error", err)
+		}
+		// Métriques Prometheus : compteurs par route, et jauges lues à chaque export.
+		if cfg.Metrics.Enabled {
+			clickEvents := api.ClickEventsChannel
+			metrics.Default.NewGaugeFunc("urlshortener_click_channel_depth", "Événements de clic en attente dans le channel des workers.",
+				func() float64 { return float64(len(clickEvents)) })
+			metrics.Default.NewGaugeFunc("urlshortener_click_channel_capacity", "Capacité du channel des événements de clic.",
+				func() float64 { return float64(cap(clickEvents)) })
+			if sqlDB, err := db.DB(); err == nil {
+				metrics.RegisterDBStats(sqlDB)
+			}
+			router.Use(api.Metrics())
+		}
+		// Authentification par clé API de /api/v1 (désactivable pour un usage local).
+		var apiKeyService *services.APIKeyService
+		if cfg.Auth.Enabled {
+			apiKeyService = services.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+		} else {
+			slog.Warn("auth.enabled est désactivé, l'API /api/v1 est accessible sans clé")
+		}
+		// Limitation du débit par client de l'API (par IP avant authentification), des créations,
+		// statistiques et redirections.
+		var rateLimiters []*ratelimit.Limiter
+		if cfg.RateLimit.Enabled {
+			newLimiter := func(rule config.RateLimitRule) *ratelimit.Limiter {
+				if rule.RequestsPerMinute <= 0 {
+					return nil
+				}
+				limiter := ratelimit.New(ratelimit.PerMinute(rule.RequestsPerMinute, rule.Burst),
+					time.Duration(cfg.RateLimit.IdleTimeoutSeconds)*time.Second)
+				limiter.Start(time.Duration(cfg.RateLimit.CleanupIntervalSeconds) * time.Second)
+				rateLimiters = append(rateLimiters, limiter)
+				return limiter
+			}
+			api.RateLimits = api.RateLimiters{
+				API:      newLimiter(cfg.RateLimit.API),
+				Create:   newLimiter(cfg.RateLimit.Create),
+				Stats:    newLimiter(cfg.RateLimit.Stats),
+				Redirect: newLimiter(cfg.RateLimit.Redirect),
+			}
+			slog.Info("Limitation du débit activée",
+				"api_per_minute", cfg.RateLimit.API.RequestsPerMinute,
+				"create_per_minute", cfg.RateLimit.Create.RequestsPerMinute,
+				"stats_per_minute", cfg.RateLimit.Stats.RequestsPerMinute,
+				"redirect_per_minute", cfg.RateLimit.Redirect.RequestsPerMinute)
+		}
+		api.SetupRoutes(router, linkService, clickService, healthService, fallbackService, apiKeyService)
+		slog.Info("Routes API configurées")
+
+		// /metrics est servi seul sur un port d'administration dédié, ou sur le port principal
+		// derrière l'authentification par clé API.
+		var adminSrv *http.Server
+		if cfg.Metrics.Enabled {
+			if cfg.Metrics.AdminPort > 0 {
+				adminMux := http.NewServeMux()
+				adminMux.Handle("/metrics", metrics.Default)
+				adminSrv = &http.Server{
+					Addr:    fmt.Sprintf(":%d", cfg.Metrics.AdminPort),
+					Handler: adminMux,
+				}
+				go func() {
+					slog.Info("Métriques exposées sur le port d'administration", "addr", adminSrv.Addr, "path", "/metrics")
+					if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+						logging.Fatal("Erreur au lancement du serveur de métriques", "error", err)
+					}
+				}()
+			} else if apiKeyService != nil {
+				router.GET("/metrics", api.APIKeyAuth(apiKeyService), api.RequireScope(services.ScopeStatsRead), api.MetricsHandler)
+			} else {
+				slog.Warn("/metrics est servi sur le port principal sans authentification (auth.enabled désactivé)")
+				router.GET("/metrics", api.MetricsHandler)
+			}
+		}
 
 		// Créer le serveur HTTP Gin
 		serverAddr := fmt.Sprintf(":%d", cfg.Server.Port)
@@ -86,9 +292,9 @@ puis lance le serveur HTTP.`,
 
 		// TODO : Démarrer le serveur Gin dans une goroutine anonyme pour ne pas bloquer.
 		go func() {
-			log.Printf("Serveur démarré sur %s\n", serverAddr)
+			slog.Info("Serveur démarré", "addr", serverAddr)
 			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				log.Fatalf("Erreur au lancement du serveur HTTP: %v", err)
+				logging.Fatal("Erreur au lancement du serveur HTTP", "error", err)
 			}
 		}()
 
@@ -99,13 +305,55 @@ puis lance le serveur HTTP.`,
 
 		// Bloquer jusqu'à ce qu'un signal d'arrêt soit reçu.
 		<-quit
-		log.Println("Signal d'arrêt reçu. Arrêt du serveur...")
+		slog.Info("Signal d'arrêt reçu, arrêt du serveur")
+
+		// Arrêt propre avec une échéance commune au serveur HTTP et à la vidange des workers.
+		shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
 
-		// Arrêt propre du serveur HTTP avec un timeout.
-		log.Println("Arrêt en cours... Donnez un peu de temps aux workers pour finir.")
-		time.Sleep(5 * time.Second)
+		// Le serveur n'accepte plus de connexions et termine les requêtes en cours. Si l'échéance est
+		// atteinte, des redirections peuvent encore produire des événements de clic : le channel n'étant
+		// jamais fermé, ils sont perdus sans faire paniquer le serveur.
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Error("Erreur lors de l'arrêt du serveur HTTP", "error", err)
+		}
+		if adminSrv != nil {
+			if err := adminSrv.Shutdown(ctx); err != nil {
+				slog.Error("Erreur lors de l'arrêt du serveur de métriques", "error", err)
+			}
+		}
+
+		urlMonitor.Stop()
+		notifier.Stop()
+		if blocklist != nil {
+			blocklist.Stop()
+		}
+		for _, limiter := range rateLimiters {
+			limiter.Stop()
+		}
+		if retentionJob != nil {
+			retentionJob.Stop()
+		}
+
+		// Les événements non encore transmis restent sur disque et seront rejoués au prochain démarrage.
+		if api.ClickQueue != nil {
+			if err := api.ClickQueue.Close(); err != nil {
+				slog.Error("Erreur lors de la fermeture de la file durable des clics", "error", err)
+			}
+		}
+
+		slog.Info("Arrêt en cours, vidange des workers de clics", "timeout", shutdownTimeout.String())
+		drain := clickWorkers.Stop(ctx)
+		slog.Info("Clics en attente à l'arrêt traités", "pending", drain.Pending, "drained", drain.Drained, "lost", drain.Lost, "deferred", drain.Deferred)
+		// Un lot en cours d'écriture à l'échéance doit se terminer avant la fermeture de la base.
+		clickWorkers.Wait()
+
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
 
-		log.Println("Serveur arrêté proprement.")
+		slog.Info("Serveur arrêté proprement")
 	},
 }
 
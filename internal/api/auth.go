@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"urlshortener/internal/models"
+	"urlshortener/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyContextKey est la clé du contexte Gin sous laquelle la clé API authentifiée est stockée.
+const apiKeyContextKey = "apiKey"
+
+// APIKeyAuth authentifie les requêtes avec une clé API fournie dans l'en-tête
+// "Authorization: Bearer <clé>" ou "X-API-Key". Sans clé valide, la requête est refusée (401).
+func APIKeyAuth(apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader("X-API-Key")
+		if auth := c.GetHeader("Authorization"); rawKey == "" && auth != "" {
+			if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+				rawKey = strings.TrimSpace(token)
+			}
+		}
+		if rawKey == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+			return
+		}
+
+		key, err := apiKeyService.Authenticate(rawKey)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Erreur lors de l'authentification de la clé API", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// RequireScope refuse (403) les requêtes dont la clé API ne possède pas la permission demandée.
+// Il doit être placé après APIKeyAuth.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := requestAPIKey(c)
+		if key == nil || !services.HasScope(key, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing scope " + scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestAPIKey retourne la clé API authentifiée de la requête, ou nil si l'authentification est désactivée.
+func requestAPIKey(c *gin.Context) *models.APIKey {
+	if value, ok := c.Get(apiKeyContextKey); ok {
+		return value.(*models.APIKey)
+	}
+	return nil
+}
+
+// requestOwner retourne l'identifiant de la clé API de la requête, utilisé pour restreindre
+// l'accès à ses propres liens, ou nil si l'authentification est désactivée.
+func requestOwner(c *gin.Context) *uint {
+	if key := requestAPIKey(c); key != nil {
+		return &key.ID
+	}
+	return nil
+}
@@ -2,12 +2,22 @@ package api
 
 import (
 	"errors"
-	"log"
+	"fmt"
+	"html"
+	"log/slog"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"urlshortener/cmd"
+	"urlshortener/internal/bots"
+	"urlshortener/internal/clickqueue"
+	"urlshortener/internal/logging"
+	"urlshortener/internal/metrics"
 	"urlshortener/internal/models"
+	"urlshortener/internal/repository"
 	"urlshortener/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -21,8 +31,22 @@ import (
 
 var ClickEventsChannel chan models.ClickEvent
 
-// SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService) {
+// ClickQueue est la file durable optionnelle placée entre le handler de redirection et les workers.
+// Lorsqu'elle est configurée, les événements y sont écrits au lieu d'être envoyés directement sur
+// ClickEventsChannel, et ne sont donc plus perdus quand le channel est plein.
+var ClickQueue *clickqueue.Queue
+
+// BotClassifier reconnaît les redirections servies aux robots, qui ne consomment pas le budget de
+// clics des liens. S'il est nil, seules les requêtes HEAD sont considérées comme des robots.
+var BotClassifier *bots.Classifier
+
+// LinkCache est le cache des liens utilisé par les redirections, s'il est activé.
+// Ses compteurs sont exposés par /health.
+var LinkCache *repository.CachedLinkRepository
+
+// SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires.
+// Si apiKeyService est nil, l'authentification par clé API de /api/v1 est désactivée.
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickService *services.ClickService, healthService *services.HealthService, fallbackService *services.FallbackService, apiKeyService *services.APIKeyService) {
 	// Le channel est initialisé ici.
 	if ClickEventsChannel == nil {
 		ClickEventsChannel = make(chan models.ClickEvent, viper.GetInt("analytics.buffer_size"))
@@ -30,113 +54,261 @@ func SetupRoutes(router *gin.Engine, linkService *services.LinkService) {
 	// TODO : Route de Health Check , /health
 	router.GET("/health", HealthCheckHandler)
 
-	apiV1 := router.Group("/api/v1")
+	// Chaque route exige une permission de la clé API, sauf si l'authentification est désactivée.
+	requireScope := func(scope string) gin.HandlerFunc {
+		if apiKeyService == nil {
+			return func(c *gin.Context) { c.Next() }
+		}
+		return RequireScope(scope)
+	}
+
+	// Le limiteur par adresse IP précède l'authentification : les requêtes sans clé valide sont
+	// aussi limitées. Les limites par clé API s'appliquent ensuite, route par route.
+	apiV1 := router.Group("/api/v1", RateLimit(RateLimits.API))
+	if apiKeyService != nil {
+		apiV1.Use(APIKeyAuth(apiKeyService))
+	}
 	{
 		// POST /links
-		apiV1.POST("/links", CreateShortLinkHandler(linkService))
+		apiV1.POST("/links", requireScope(services.ScopeLinksWrite), RateLimit(RateLimits.Create), CreateShortLinkHandler(linkService))
+		// GET /links
+		apiV1.GET("/links", requireScope(services.ScopeLinksRead), ListLinksHandler(linkService))
+		// GET /links/:shortCode
+		apiV1.GET("/links/:shortCode", requireScope(services.ScopeLinksRead), GetLinkHandler(linkService))
+		// PATCH /links/:shortCode
+		apiV1.PATCH("/links/:shortCode", requireScope(services.ScopeLinksWrite), UpdateLinkHandler(linkService))
+		// DELETE /links/:shortCode
+		apiV1.DELETE("/links/:shortCode", requireScope(services.ScopeLinksWrite), DeleteLinkHandler(linkService))
+		// POST /links/:shortCode/restore
+		apiV1.POST("/links/:shortCode/restore", requireScope(services.ScopeLinksWrite), RestoreLinkHandler(linkService))
 		// GET /links/:shortCode/stats
-		apiV1.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+		apiV1.GET("/links/:shortCode/stats", requireScope(services.ScopeStatsRead), RateLimit(RateLimits.Stats), GetLinkStatsHandler(linkService, clickService))
+		// GET /links/:shortCode/health
+		apiV1.GET("/links/:shortCode/health", requireScope(services.ScopeStatsRead), RateLimit(RateLimits.Stats), GetLinkHealthHandler(linkService, healthService))
+		// GET /links/:shortCode/clicks/timeseries
+		apiV1.GET("/links/:shortCode/clicks/timeseries", requireScope(services.ScopeStatsRead), RateLimit(RateLimits.Stats), GetClickTimeSeriesHandler(linkService, clickService))
 	}
 	// Route de Redirection (au niveau racine pour les short codes)
-	router.GET("/:shortCode", RedirectHandler(linkService))
+	router.GET("/:shortCode", RateLimit(RateLimits.Redirect), RedirectHandler(linkService, fallbackService))
+	// Les requêtes HEAD (vérifications de liens, aperçus) sont redirigées et enregistrées comme clics de robots.
+	router.HEAD("/:shortCode", RateLimit(RateLimits.Redirect), RedirectHandler(linkService, fallbackService))
 }
 
 // HealthCheckHandler gère la route /health pour vérifier l'état du service.
 func HealthCheckHandler(c *gin.Context) {
 	// TODO  Retourner simplement du JSON avec un StatusOK, {"status": "ok"}
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	response := gin.H{"status": "ok"}
+	if LinkCache != nil {
+		response["link_cache"] = LinkCache.Stats()
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateLinkRequest représente le corps de la requête JSON pour la création d'un lien.
 type CreateLinkRequest struct {
-	LongURL string `json:"long_url" binding:"required,url"` // 'binding:required' pour validation, 'url' pour format URL
+	LongURL   string     `json:"long_url" binding:"required,url"` // 'binding:required' pour validation, 'url' pour format URL
+	Alias     string     `json:"alias"`                           // Alias personnalisé optionnel (ex: "spring-sale")
+	ExpiresAt *time.Time `json:"expires_at"`                      // Date d'expiration optionnelle (RFC 3339)
+	MaxClicks *int       `json:"max_clicks"`                      // Nombre maximum de clics optionnel
+	Tags      []string   `json:"tags"`                            // Étiquettes optionnelles, utilisées pour router les notifications
+
+	FallbackURL string `json:"fallback_url" binding:"omitempty,url"` // URL servie quand l'URL longue est inaccessible (optionnel)
 }
 
 // CreateShortLinkHandler gère la création d'une URL courte.
 func CreateShortLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req CreateLinkRequest
-		
+
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 		// TODO: Appeler le LinkService (CreateLink pour créer le nouveau lien.
-		link, err := linkService.CreateLink(req.LongURL)
+		link, err := linkService.CreateLink(req.LongURL, services.LinkOptions{
+			Alias:       req.Alias,
+			ExpiresAt:   req.ExpiresAt,
+			MaxClicks:   req.MaxClicks,
+			APIKeyID:    requestOwner(c),
+			Tags:        req.Tags,
+			FallbackURL: req.FallbackURL,
+		})
 		if err != nil {
+			// URL de destination refusée par la politique de sécurité.
+			if respondURLRejected(c, err) {
+				return
+			}
+			// Les erreurs métier sur l'alias sont renvoyées telles quelles au client.
+			if errors.Is(err, services.ErrAliasTaken) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrInvalidAlias) || errors.Is(err, services.ErrReservedAlias) ||
+				errors.Is(err, services.ErrInvalidExpiration) || errors.Is(err, services.ErrInvalidTag) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			// Si une erreur se produit, retourner un code HTTP 500 (Internal Server Error).
-			log.Printf("Erreur lors de la création du lien: %v", err)
+			slog.ErrorContext(c.Request.Context(), "Erreur lors de la création du lien", "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 			return
 		}
 
 		// Retourne le code court et l'URL longue dans la réponse JSON.
-		c.JSON(http.StatusCreated, gin.H{
-			"short_code":     link.ShortCode,
-			"long_url":       link.LongURL,
-			"full_short_url": cmd.Cfg.Server.BaseURL + link.ShortCode, // Utilise la base URL du serveur configurée
-		})
+		c.JSON(http.StatusCreated, linkResponse(link))
 	}
 }
 
 // RedirectHandler gère la redirection d'une URL courte vers l'URL longue et l'enregistrement asynchrone des clics.
-func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
+// Quand le moniteur constate que l'URL longue est inaccessible, fallbackService redirige vers l'URL de repli.
+func RedirectHandler(linkService *services.LinkService, fallbackService *services.FallbackService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Récupère le shortCode de l'URL avec c.Param
 		shortCode := c.Param("shortCode")
 
-		link,err := linkService.GetLinkByShortCode(shortCode)
+		link, err := linkService.ResolveLink(shortCode)
 
 		if err != nil {
+			// Si le lien a expiré, retourner HTTP 410 Gone sans enregistrer de clic.
+			if errors.Is(err, services.ErrLinkExpired) {
+				metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
+				respondLinkExpired(c)
+				return
+			}
 			// Si le lien n'est pas trouvé, retourner HTTP 404 Not Found.
 			// Utiliser errors.Is et l'erreur Gorm
-			if errors.Is(err, gorm.ErrRecordNotFound) { 
+			if errors.Is(err, gorm.ErrRecordNotFound) {
 				// Utilisez errors.Is(err, gorm.ErrRecordNotFound) en production si l'erreur est wrappée
+				metrics.Redirects.WithLabelValues(metrics.RedirectNotFound).Inc()
 				c.JSON(http.StatusNotFound, gin.H{"error": "Lien introuvable"})
 				return
 			} else if errors.Is(err, gorm.ErrInvalidValue) {
 				// Si l'erreur est une valeur invalide, retourner HTTP 400 Bad Request.
+				metrics.Redirects.WithLabelValues(metrics.RedirectInvalid).Inc()
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Lien invalide"})
 				return
 			}
-				// Gérer d'autres erreurs potentielles de la base de données ou du service
-				log.Printf("Error retrieving link for %s: %v", shortCode, err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			// Gérer d'autres erreurs potentielles de la base de données ou du service
+			slog.ErrorContext(c.Request.Context(), "Erreur lors de la récupération du lien", "short_code", shortCode, "error", err)
+			metrics.Redirects.WithLabelValues(metrics.RedirectError).Inc()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 			return
 		}
 
+		if link == nil || link.LongURL == "" {
+			// Si le lien est introuvable ou l'URL longue est vide, retourner HTTP 404 Not Found.
+			metrics.Redirects.WithLabelValues(metrics.RedirectNotFound).Inc()
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lien introuvable"})
+			return
+		}
+		target, fallback := fallbackService.RedirectTarget(link)
 		clickEvent := models.ClickEvent{
 			LinkID:    link.ID,
 			Timestamp: time.Now(),
 			UserAgent: c.Request.UserAgent(),
 			IPAddress: c.ClientIP(),
+			Referrer:  c.Request.Referer(),
+			Method:    c.Request.Method,
+			Accept:    c.GetHeader("Accept"),
+			Fallback:  fallback,
+			RequestID: logging.RequestID(c.Request.Context()),
+		}
+
+		// Le budget de clics est décompté dès la redirection, et seulement pour les visiteurs :
+		// les aperçus de liens et les requêtes HEAD ne l'épuisent pas.
+		if link.MaxClicks != nil && !isBotClick(clickEvent) {
+			if err := linkService.ReserveClick(link); err != nil {
+				if errors.Is(err, services.ErrLinkExpired) {
+					metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
+					respondLinkExpired(c)
+					return
+				}
+				slog.ErrorContext(c.Request.Context(), "Erreur lors du décompte du budget de clics", "short_code", shortCode, "error", err)
+				metrics.Redirects.WithLabelValues(metrics.RedirectError).Inc()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
 		}
 
-		select {
-		case ClickEventsChannel <- clickEvent:
-			// Si l'envoi est réussi, on continue
-		default:
-			log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+		if fallback {
+			metrics.Redirects.WithLabelValues(metrics.RedirectFallback).Inc()
+		} else {
+			metrics.Redirects.WithLabelValues(metrics.RedirectFound).Inc()
 		}
 
-		if link == nil || link.LongURL == "" {
-			// Si le lien est introuvable ou l'URL longue est vide, retourner HTTP 404 Not Found.
-			c.JSON(http.StatusNotFound, gin.H{"error": "Lien introuvable"})
-			return
+		if ClickQueue != nil {
+			if err := ClickQueue.Append(clickEvent); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Impossible d'écrire l'événement de clic dans la file durable", "short_code", shortCode, "error", err)
+				metrics.ClickEventsDropped.Inc()
+			}
+		} else {
+			select {
+			case ClickEventsChannel <- clickEvent:
+				// Si l'envoi est réussi, on continue
+			default:
+				slog.WarnContext(c.Request.Context(), "Channel des événements de clic plein, événement perdu", "short_code", shortCode)
+				metrics.ClickEventsDropped.Inc()
+			}
 		}
 
-		c.Redirect(http.StatusFound, link.LongURL)
+		c.Redirect(http.StatusFound, target)
+
+	}
+}
 
+// isBotClick indique si une redirection est servie à un robot (voir BotClassifier).
+func isBotClick(event models.ClickEvent) bool {
+	if BotClassifier == nil {
+		return event.Method == http.MethodHead
 	}
+	return BotClassifier.IsBot(event)
+}
+
+// respondLinkExpired répond HTTP 410 Gone pour un lien expiré.
+// Selon la configuration, le corps est une page HTML personnalisée, une page qui redirige
+// vers une URL de repli, ou un simple message JSON.
+func respondLinkExpired(c *gin.Context) {
+	cfg := cmd.Cfg.Expiration
+
+	if cfg.FallbackPage != "" {
+		page, err := os.ReadFile(cfg.FallbackPage)
+		if err == nil {
+			c.Data(http.StatusGone, "text/html; charset=utf-8", page)
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Erreur de lecture de la page des liens expirés", "file", cfg.FallbackPage, "error", err)
+	}
+
+	if cfg.FallbackURL != "" {
+		target := html.EscapeString(cfg.FallbackURL)
+		page := fmt.Sprintf(`<!DOCTYPE html><html><head><meta charset="utf-8"><meta http-equiv="refresh" content="0; url=%s"><title>Lien expiré</title></head><body><p>Ce lien a expiré. <a href="%s">Continuer</a></p></body></html>`, target, target)
+		c.Data(http.StatusGone, "text/html; charset=utf-8", []byte(page))
+		return
+	}
+
+	c.JSON(http.StatusGone, gin.H{"error": "Lien expiré"})
 }
 
 // GetLinkStatsHandler gère la récupération des statistiques pour un lien spécifique.
-func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
+// Le paramètre optionnel 'top' limite le nombre d'entrées de chaque répartition (referrers, navigateurs...)
+// et 'include_bots=true' inclut les clics attribués à des robots. Les visiteurs uniques sont calculés
+// sur la plage from/to (30 derniers jours par défaut), par jour UTC : 'timezone' ne s'applique qu'à
+// l'interprétation des dates from/to, les empreintes de visiteurs étant renouvelées chaque jour UTC.
+func GetLinkStatsHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		shortCode := c.Param("shortCode")
 
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		top, err := parseIntParam(c, "top", defaultBreakdownSize)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if top > maxBreakdownSize {
+			top = maxBreakdownSize
+		}
+
+		link, err := linkService.GetLink(shortCode, requestOwner(c))
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				// Si le lien n'est pas trouvé, retourner HTTP 404 Not Found.
@@ -148,24 +320,401 @@ func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 				return
 			}
 			// Gérer d'autres erreurs potentielles de la base de données ou du service
-			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			slog.ErrorContext(c.Request.Context(), "Erreur lors de la récupération du lien", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+
+		}
+
+		filter, err := parseClickFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		from, to, _, err := parseTimeRange(c, services.IntervalDay.DefaultSpan())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, totalClicks, err := linkService.GetLinkStats(shortCode, filter, requestOwner(c))
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			slog.ErrorContext(c.Request.Context(), "Erreur lors de la récupération des statistiques du lien", "short_code", shortCode, "error", err)
 			return
+		}
 
+		breakdowns, err := clickService.GetClickBreakdowns(link.ID, top, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			slog.ErrorContext(c.Request.Context(), "Erreur lors de la répartition des clics", "short_code", shortCode, "error", err)
+			return
 		}
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		uniqueVisitors, err := clickService.GetUniqueVisitors(link.ID, from, to, filter)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			slog.ErrorContext(c.Request.Context(), "Erreur lors du comptage des visiteurs uniques", "short_code", shortCode, "error", err)
+			return
+		}
+
+		// Le budget de clics ne compte pas les robots : il est décompté par les redirections (clicks_used).
+		expired := link.HasExpired(time.Now())
+
+		// Contrairement au budget de clics, le décompte des redirections de repli inclut les robots.
+		fallbackRedirects, err := clickService.GetFallbackRedirects(link.ID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-			log.Printf("Error retrieving link stats for %s: %v", shortCode, err)
+			slog.ErrorContext(c.Request.Context(), "Erreur lors du comptage des redirections de repli", "short_code", shortCode, "error", err)
 			return
 		}
 
 		// Retourne les statistiques dans la réponse JSON.
 		c.JSON(http.StatusOK, gin.H{
-			"short_code":   link.ShortCode,
-			"long_url":     link.LongURL,
-			"total_clicks": totalClicks,
+			"short_code":         link.ShortCode,
+			"long_url":           link.LongURL,
+			"total_clicks":       totalClicks,
+			"expires_at":         link.ExpiresAt,
+			"max_clicks":         link.MaxClicks,
+			"clicks_used":        link.ClicksUsed,
+			"expired":            expired,
+			"fallback_url":       link.FallbackURL,
+			"fallback_redirects": fallbackRedirects,
+			"breakdowns":         breakdowns,
+			"include_bots":       filter.IncludeBots,
+			// Les visiteurs uniques sont comptés par jour UTC, le jour de renouvellement des empreintes.
+			"unique_visitors": gin.H{
+				"from":        from.UTC(),
+				"to":          to.UTC(),
+				"timezone":    time.UTC.String(),
+				"total":       uniqueVisitors.Total,
+				"approximate": uniqueVisitors.Approximate,
+				"daily":       uniqueVisitors.Daily,
+			},
+		})
+	}
+}
+
+// Bornes de pagination pour GET /api/v1/links.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Nombre d'entrées par répartition dans les statistiques d'un lien.
+const (
+	defaultBreakdownSize = 5
+	maxBreakdownSize     = 50
+)
+
+// UpdateLinkRequest représente le corps de la requête JSON pour la modification d'un lien.
+type UpdateLinkRequest struct {
+	LongURL string `json:"long_url" binding:"required,url"` // Nouvelle URL de destination
+}
+
+// linkResponse construit la représentation JSON d'un lien renvoyée par l'API.
+func linkResponse(link *models.Link) gin.H {
+	return gin.H{
+		"short_code":     link.ShortCode,
+		"long_url":       link.LongURL,
+		"full_short_url": strings.TrimSuffix(cmd.Cfg.Server.BaseURL, "/") + "/" + link.ShortCode, // Utilise la base URL du serveur configurée
+		"created_at":     link.CreatedAt,
+		"updated_at":     link.UpdatedAt,
+		"expires_at":     link.ExpiresAt,
+		"max_clicks":     link.MaxClicks,
+		"tags":           link.TagList(),
+		"fallback_url":   link.FallbackURL,
+	}
+}
+
+// respondURLRejected répond 422 avec le code de refus si err provient d'une URLPolicy.
+// Il indique si une réponse a été envoyée.
+func respondURLRejected(c *gin.Context, err error) bool {
+	var rejected *services.URLRejectedError
+	if !errors.As(err, &rejected) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": rejected.Error(), "reason": rejected.Reason})
+	return true
+}
+
+// respondLinkError traduit une erreur de recherche de lien en réponse HTTP.
+func respondLinkError(c *gin.Context, shortCode string, err error) {
+	if respondURLRejected(c, err) {
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lien introuvable"})
+		return
+	}
+	if errors.Is(err, gorm.ErrInvalidValue) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Lien invalide"})
+		return
+	}
+	slog.ErrorContext(c.Request.Context(), "Erreur lors du traitement du lien", "short_code", shortCode, "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+// parseDateParam lit un paramètre de requête contenant une date au format RFC 3339 ou AAAA-MM-JJ
+// (voir services.ParseDate). Il retourne nil si le paramètre est absent.
+func parseDateParam(c *gin.Context, name string, loc *time.Location) (*time.Time, error) {
+	t, err := services.ParseDate(c.Query(name), loc)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a RFC 3339 date or YYYY-MM-DD", name)
+	}
+	return t, nil
+}
+
+// parseIntParam lit un paramètre de requête entier strictement positif, avec une valeur par défaut.
+func parseIntParam(c *gin.Context, name string, defaultValue int) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// parseTimeRange lit les paramètres from, to et timezone communs aux statistiques temporelles.
+// Par défaut, 'to' vaut maintenant et 'from' vaut 'to' moins defaultSpan.
+func parseTimeRange(c *gin.Context, defaultSpan time.Duration) (time.Time, time.Time, *time.Location, error) {
+	loc, err := time.LoadLocation(c.DefaultQuery("timezone", "UTC"))
+	if err != nil {
+		return time.Time{}, time.Time{}, nil, fmt.Errorf("invalid timezone")
+	}
+
+	to := time.Now()
+	if t, err := parseDateParam(c, "to", loc); err != nil {
+		return time.Time{}, time.Time{}, nil, err
+	} else if t != nil {
+		to = *t
+	}
+	from := to.Add(-defaultSpan)
+	if t, err := parseDateParam(c, "from", loc); err != nil {
+		return time.Time{}, time.Time{}, nil, err
+	} else if t != nil {
+		from = *t
+	}
+	return from, to, loc, nil
+}
+
+// parseClickFilter construit le filtre des statistiques de clics à partir des paramètres de requête.
+// Les clics de robots sont exclus sauf si include_bots=true.
+func parseClickFilter(c *gin.Context) (repository.ClickFilter, error) {
+	var filter repository.ClickFilter
+	if value := c.Query("include_bots"); value != "" {
+		includeBots, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("include_bots must be a boolean")
+		}
+		filter.IncludeBots = includeBots
+	}
+	return filter, nil
+}
+
+// ListLinksHandler gère la liste paginée des liens.
+// Paramètres : page, page_size, created_after, created_before, url_contains.
+func ListLinksHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := parseIntParam(c, "page", 1)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		pageSize, err := parseIntParam(c, "page_size", defaultPageSize)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if pageSize > maxPageSize {
+			pageSize = maxPageSize
+		}
+
+		filter := repository.LinkFilter{
+			URLContains: c.Query("url_contains"),
+			OwnerKeyID:  requestOwner(c),
+			Offset:      (page - 1) * pageSize,
+			Limit:       pageSize,
+		}
+		if filter.CreatedAfter, err = parseDateParam(c, "created_after", time.UTC); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if filter.CreatedBefore, err = parseDateParam(c, "created_before", time.UTC); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		links, total, err := linkService.ListLinks(filter)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Erreur lors de la liste des liens", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(links))
+		for i := range links {
+			items = append(items, linkResponse(&links[i]))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"links":     items,
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		})
+	}
+}
+
+// GetLinkHandler gère la récupération des détails d'un lien.
+func GetLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.GetLink(shortCode, requestOwner(c))
+		if err != nil {
+			respondLinkError(c, shortCode, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link))
+	}
+}
+
+// UpdateLinkHandler gère la modification de l'URL de destination d'un lien.
+func UpdateLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, err := linkService.UpdateLinkURL(shortCode, req.LongURL, requestOwner(c))
+		if err != nil {
+			respondLinkError(c, shortCode, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link))
+	}
+}
+
+// DeleteLinkHandler gère la suppression logique d'un lien.
+func DeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		if err := linkService.DeleteLink(shortCode, requestOwner(c)); err != nil {
+			respondLinkError(c, shortCode, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RestoreLinkHandler gère la restauration d'un lien précédemment supprimé.
+func RestoreLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.RestoreLink(shortCode, requestOwner(c))
+		if err != nil {
+			respondLinkError(c, shortCode, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link))
+	}
+}
+
+// GetClickTimeSeriesHandler gère la récupération du nombre de clics d'un lien par période.
+// Paramètres : from, to (RFC 3339 ou AAAA-MM-JJ), interval (hour, day, week), timezone (nom IANA)
+// et include_bots.
+func GetClickTimeSeriesHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		interval, err := services.ParseInterval(c.DefaultQuery("interval", string(services.IntervalDay)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		from, to, loc, err := parseTimeRange(c, interval.DefaultSpan())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		filter, err := parseClickFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, err := linkService.GetLink(shortCode, requestOwner(c))
+		if err != nil {
+			respondLinkError(c, shortCode, err)
+			return
+		}
+
+		buckets, err := clickService.GetClickTimeSeries(link.ID, from, to, interval, loc, filter)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Erreur lors de la récupération de la série temporelle des clics", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.ShortCode,
+			"interval":   interval,
+			"timezone":   loc.String(),
+			"from":       from.In(loc),
+			"to":         to.In(loc),
+			"buckets":    buckets,
+		})
+	}
+}
+
+// GetLinkHealthHandler gère la récupération de l'état de santé d'un lien : statut de sa dernière
+// vérification par le moniteur, date du dernier changement d'état et disponibilité sur 24h, 7j et 30j.
+func GetLinkHealthHandler(linkService *services.LinkService, healthService *services.HealthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.GetLink(shortCode, requestOwner(c))
+		if err != nil {
+			respondLinkError(c, shortCode, err)
+			return
+		}
+
+		health, err := healthService.GetLinkHealth(link)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Erreur lors de la récupération de l'état de santé du lien", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.ShortCode,
+			"long_url":   link.LongURL,
+			"health":     health,
 		})
 	}
 }
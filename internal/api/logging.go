@@ -0,0 +1,96 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"urlshortener/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader est l'en-tête portant l'identifiant de requête, reçu du client ou d'un proxy
+// et renvoyé dans chaque réponse.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength borne les identifiants reçus, stockés tels quels avec les clics.
+const maxRequestIDLength = 64
+
+// RequestID reprend l'identifiant de requête de l'en-tête X-Request-ID, ou en génère un s'il est
+// absent ou invalide, le renvoie dans la réponse et le place dans le contexte de la requête :
+// les lignes journalisées avec ce contexte et le clic enregistré le portent.
+// Il doit être placé en premier pour couvrir toutes les requêtes.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validRequestID indique si un identifiant reçu peut être repris : non vide, de longueur bornée
+// et composé uniquement de lettres, chiffres et des caractères . _ : -
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == ':', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID génère un identifiant aléatoire de 32 caractères hexadécimaux.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// AccessLog journalise chaque requête une fois traitée : méthode, route, chemin, statut, durée et
+// adresse du client. Les réponses 5xx sont journalisées en Error.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		slog.LogAttrs(c.Request.Context(), level, "Requête HTTP traitée",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(started).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		)
+	}
+}
+
+// Recovery répond 500 à une requête dont le traitement panique, et journalise la panique.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "Panique lors du traitement de la requête", "panic", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	})
+}
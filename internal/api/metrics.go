@@ -0,0 +1,35 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"urlshortener/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute est l'étiquette des requêtes ne correspondant à aucune route, pour borner
+// le nombre de séries quel que soit le chemin demandé.
+const unmatchedRoute = "unmatched"
+
+// Metrics compte chaque requête par méthode, route et code de statut, et mesure sa durée.
+// Il doit être placé avant les routes pour couvrir toutes les requêtes.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.ObserveSince(metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route), started)
+	}
+}
+
+// MetricsHandler expose les métriques du service au format texte de Prometheus.
+func MetricsHandler(c *gin.Context) {
+	metrics.Default.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"urlshortener/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimiters regroupe les limiteurs de débit par famille de routes.
+// Un limiteur nil désactive la limitation de la famille correspondante.
+type RateLimiters struct {
+	API      *ratelimit.Limiter // Toutes les routes /api/v1, par adresse IP, avant l'authentification
+	Create   *ratelimit.Limiter // POST /api/v1/links
+	Stats    *ratelimit.Limiter // Statistiques et séries temporelles
+	Redirect *ratelimit.Limiter // Redirections /:shortCode
+}
+
+// RateLimits contient les limiteurs appliqués par SetupRoutes, configurés au démarrage du serveur.
+var RateLimits RateLimiters
+
+// RateLimit limite le débit des requêtes par client : par clé API si la requête est authentifiée
+// (il doit alors être placé après APIKeyAuth), sinon par adresse IP. Les en-têtes X-RateLimit-*
+// sont ajoutés à chaque réponse (un limiteur placé ensuite remplace ceux d'un limiteur précédent) ; au-delà de la limite, la requête est refusée (429) avec Retry-After.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	if limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		result := limiter.Allow(rateLimitKey(c))
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitKey retourne l'identifiant du client utilisé pour choisir son seau.
+func rateLimitKey(c *gin.Context) string {
+	if key := requestAPIKey(c); key != nil {
+		return "key:" + strconv.FormatUint(uint64(key.ID), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds arrondit une durée à la seconde supérieure, comme attendu par Retry-After.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,79 @@
+package bots
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"urlshortener/internal/models"
+)
+
+// defaultPatterns est la liste de motifs livrée avec le binaire.
+//
+//go:embed patterns.txt
+var defaultPatterns string
+
+// Classifier détermine si un événement de clic provient d'un robot, d'un crawler
+// ou d'un générateur d'aperçu de lien plutôt que d'un visiteur humain.
+type Classifier struct {
+	patterns []string // Motifs en minuscules recherchés dans le User-Agent
+}
+
+// NewClassifier crée un classifieur à partir d'une liste de motifs de User-Agent.
+func NewClassifier(patterns []string) *Classifier {
+	normalized := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" {
+			normalized = append(normalized, p)
+		}
+	}
+	return &Classifier{patterns: normalized}
+}
+
+// DefaultPatterns retourne la liste de motifs intégrée au binaire.
+func DefaultPatterns() []string {
+	return parsePatterns(defaultPatterns)
+}
+
+// LoadPatterns lit une liste de motifs depuis un fichier (un motif par ligne, '#' pour les commentaires).
+func LoadPatterns(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bot patterns file: %w", err)
+	}
+	return parsePatterns(string(data)), nil
+}
+
+// parsePatterns découpe le contenu d'un fichier de motifs en ignorant les commentaires et lignes vides.
+func parsePatterns(content string) []string {
+	var patterns []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+// IsBot indique si l'événement doit être considéré comme provenant d'un robot.
+// En plus des motifs de User-Agent, les heuristiques suivantes sont appliquées :
+// User-Agent absent, requête HEAD (vérification de lien) et en-tête Accept absent,
+// que tous les navigateurs envoient.
+func (c *Classifier) IsBot(event models.ClickEvent) bool {
+	if event.UserAgent == "" || event.Method == http.MethodHead || event.Accept == "" {
+		return true
+	}
+
+	ua := strings.ToLower(event.UserAgent)
+	for _, p := range c.patterns {
+		if strings.Contains(ua, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,409 @@
+// Package clickqueue fournit un journal durable sur disque placé entre le handler de redirection
+// et les workers de clics : les événements y sont ajoutés sans jamais bloquer ni être perdus quand
+// le channel est plein, rejoués au démarrage après un arrêt, et supprimés une fois persistés.
+package clickqueue
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"urlshortener/internal/models"
+)
+
+// defaultSegmentMaxBytes est la taille maximale d'un segment si Options.SegmentMaxBytes n'est pas renseigné.
+const defaultSegmentMaxBytes = 4 << 20
+
+// segmentIdleRotation est l'âge minimal du segment actif avant qu'il ne soit clos alors que tous ses
+// événements ont été transmis : cela permet de supprimer rapidement les événements persistés sans
+// créer un fichier par clic.
+const segmentIdleRotation = time.Second
+
+// ErrClosed est retournée par Append une fois la file fermée.
+var ErrClosed = errors.New("click queue closed")
+
+// Options configure une Queue.
+type Options struct {
+	Dir             string        // Répertoire des segments
+	SyncInterval    time.Duration // Délai entre deux fsync du segment actif. 0 = fsync à chaque événement.
+	SegmentMaxBytes int64         // Taille au-delà de laquelle un nouveau segment est ouvert
+}
+
+// segmentState suit l'avancement d'un segment présent sur disque.
+type segmentState struct {
+	size       int64 // Octets écrits dans le segment
+	readOffset int64 // Octets déjà transmis aux workers
+	pending    int   // Événements transmis aux workers et pas encore acquittés
+	sealed     bool  // Le segment ne reçoit plus d'écritures
+}
+
+// Queue est un journal de clics en ajout seul, découpé en segments numérotés.
+// Un segment est supprimé lorsqu'il est clos, entièrement transmis et que tous ses
+// événements ont été acquittés par les workers (livraison "au moins une fois").
+type Queue struct {
+	opts   Options
+	logger *slog.Logger // Journal de la file (component=click_queue)
+
+	mu            sync.Mutex
+	segments      map[uint64]*segmentState
+	active        *os.File
+	activeSeq     uint64
+	activeCreated time.Time
+	dirty         bool // Des écritures n'ont pas encore été synchronisées
+	closed        bool
+
+	notify chan struct{} // Signale au dispatcher qu'un événement a été ajouté
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// Open ouvre (ou crée) la file dans opts.Dir. Les segments laissés par une exécution précédente
+// seront rejoués par le dispatcher lancé avec Start ; les nouveaux événements sont écrits dans un nouveau segment.
+func Open(opts Options) (*Queue, error) {
+	if opts.SegmentMaxBytes <= 0 {
+		opts.SegmentMaxBytes = defaultSegmentMaxBytes
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating queue directory: %w", err)
+	}
+
+	seqs, err := listSegments(opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing queue segments: %w", err)
+	}
+
+	q := &Queue{
+		opts:     opts,
+		logger:   slog.With("component", "click_queue"),
+		segments: make(map[uint64]*segmentState),
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	var last uint64
+	for _, seq := range seqs {
+		info, err := os.Stat(segmentPath(opts.Dir, seq))
+		if err != nil {
+			return nil, fmt.Errorf("error reading queue segment: %w", err)
+		}
+		q.segments[seq] = &segmentState{size: info.Size(), sealed: true}
+		last = seq
+	}
+	if len(seqs) > 0 {
+		q.logger.Info("Segments de clics en attente à rejouer", "segments", len(seqs))
+	}
+
+	if err := q.openSegmentLocked(last + 1); err != nil {
+		return nil, err
+	}
+
+	if opts.SyncInterval > 0 {
+		q.wg.Add(1)
+		go q.syncLoop()
+	}
+	return q, nil
+}
+
+// Start lance le dispatcher qui lit les segments dans l'ordre et transmet les événements sur 'out'.
+// L'envoi est bloquant : quand les workers sont saturés, les événements restent sur disque.
+func (q *Queue) Start(out chan<- models.ClickEvent) {
+	q.wg.Add(1)
+	go q.dispatch(out)
+}
+
+// Append ajoute un événement au segment actif. L'écriture est visible du dispatcher immédiatement
+// et synchronisée sur disque au plus tard après Options.SyncInterval.
+func (q *Queue) Append(event models.ClickEvent) error {
+	record, err := encodeRecord(event)
+	if err != nil {
+		return fmt.Errorf("error encoding click event: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+
+	st := q.segments[q.activeSeq]
+	if st.size > 0 && st.size+int64(len(record)) > q.opts.SegmentMaxBytes {
+		if err := q.rotateLocked(); err != nil {
+			return err
+		}
+		st = q.segments[q.activeSeq]
+	}
+
+	n, err := q.active.Write(record)
+	st.size += int64(n)
+	if err != nil {
+		// Le segment se termine par un enregistrement tronqué : on le clôt pour que le dispatcher l'ignore.
+		q.rotateLocked()
+		return fmt.Errorf("error writing click event: %w", err)
+	}
+
+	if q.opts.SyncInterval <= 0 {
+		if err := q.active.Sync(); err != nil {
+			return fmt.Errorf("error syncing click queue: %w", err)
+		}
+	} else {
+		q.dirty = true
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Ack signale que des événements transmis par le dispatcher ont été persistés.
+// Les segments dont tous les événements sont acquittés sont supprimés.
+func (q *Queue) Ack(events []models.ClickEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, event := range events {
+		if st := q.segments[event.QueueSegment]; st != nil {
+			st.pending--
+			q.removeIfDoneLocked(event.QueueSegment)
+		}
+	}
+}
+
+// Close synchronise et clôt le segment actif, puis arrête le dispatcher. Les événements non transmis
+// restent sur disque et seront rejoués au prochain démarrage ; Ack reste utilisable après Close.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+
+	err := q.active.Sync()
+	q.active.Close()
+	q.segments[q.activeSeq].sealed = true
+	q.removeIfDoneLocked(q.activeSeq)
+	q.mu.Unlock()
+
+	close(q.done)
+	q.wg.Wait()
+	return err
+}
+
+// openSegmentLocked crée le segment seq et en fait le segment actif.
+func (q *Queue) openSegmentLocked(seq uint64) error {
+	f, err := os.OpenFile(segmentPath(q.opts.Dir, seq), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating queue segment: %w", err)
+	}
+	syncDir(q.opts.Dir)
+
+	q.active = f
+	q.activeSeq = seq
+	q.activeCreated = time.Now()
+	q.segments[seq] = &segmentState{}
+	return nil
+}
+
+// rotateLocked synchronise et clôt le segment actif, puis ouvre le suivant.
+func (q *Queue) rotateLocked() error {
+	if err := q.active.Sync(); err != nil {
+		q.logger.Error("Erreur lors de la synchronisation du segment", "segment", q.activeSeq, "error", err)
+	}
+	q.active.Close()
+	q.dirty = false
+
+	sealed := q.activeSeq
+	q.segments[sealed].sealed = true
+	if err := q.openSegmentLocked(sealed + 1); err != nil {
+		return err
+	}
+	q.removeIfDoneLocked(sealed)
+	return nil
+}
+
+// removeIfDoneLocked supprime le segment seq s'il est clos, entièrement transmis et acquitté.
+func (q *Queue) removeIfDoneLocked(seq uint64) {
+	st := q.segments[seq]
+	if st == nil || !st.sealed || st.pending > 0 || st.readOffset < st.size {
+		return
+	}
+	if err := os.Remove(segmentPath(q.opts.Dir, seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		q.logger.Error("Erreur lors de la suppression du segment", "segment", seq, "error", err)
+		return
+	}
+	delete(q.segments, seq)
+}
+
+// syncLoop synchronise périodiquement le segment actif s'il a reçu des écritures.
+func (q *Queue) syncLoop() {
+	defer q.wg.Done()
+	ticker := time.NewTicker(q.opts.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-ticker.C:
+		}
+
+		q.mu.Lock()
+		f, dirty := q.active, q.dirty
+		q.dirty = false
+		q.mu.Unlock()
+
+		// Un segment clos entre-temps a déjà été synchronisé par rotateLocked ou Close.
+		if dirty {
+			if err := f.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+				q.logger.Error("Erreur lors de la synchronisation de la file de clics", "error", err)
+			}
+		}
+	}
+}
+
+// dispatch transmet les segments l'un après l'autre jusqu'à la fermeture de la file.
+func (q *Queue) dispatch(out chan<- models.ClickEvent) {
+	defer q.wg.Done()
+
+	seq, ok := q.nextSegment(0)
+	for ok {
+		if !q.dispatchSegment(seq, out) {
+			return
+		}
+		seq, ok = q.nextSegment(seq)
+	}
+}
+
+// nextSegment retourne le plus petit segment de numéro supérieur à after.
+func (q *Queue) nextSegment(after uint64) (uint64, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	next, found := uint64(0), false
+	for seq := range q.segments {
+		if seq > after && (!found || seq < next) {
+			next, found = seq, true
+		}
+	}
+	return next, found
+}
+
+// dispatchSegment transmet les événements du segment seq à partir de la position déjà lue.
+// Sur le segment actif, elle attend les nouveaux événements jusqu'à sa clôture.
+// Elle retourne false si la file a été fermée.
+func (q *Queue) dispatchSegment(seq uint64, out chan<- models.ClickEvent) bool {
+	f, err := os.Open(segmentPath(q.opts.Dir, seq))
+	if err != nil {
+		q.logger.Error("Segment illisible, ignoré", "segment", seq, "error", err)
+		q.finishSegment(seq)
+		return true
+	}
+	defer f.Close()
+
+	offset, sealed := q.segmentPosition(seq)
+	for {
+		event, n, err := readRecord(f, offset)
+		switch {
+		case err == nil:
+			offset += n
+			event.QueueSegment = seq
+			q.markDispatched(seq, offset)
+			select {
+			case out <- event:
+			case <-q.done:
+				return false
+			}
+
+		case errors.Is(err, io.EOF) && !sealed:
+			// Segment actif entièrement transmis : on attend de nouveaux événements.
+			q.rotateIfIdle(seq)
+			select {
+			case <-q.notify:
+			case <-time.After(segmentIdleRotation):
+			case <-q.done:
+				return false
+			}
+
+		default:
+			if !errors.Is(err, io.EOF) || offset < q.segmentSize(seq) {
+				q.logger.Warn("Fin de segment illisible ignorée", "segment", seq, "offset", offset, "error", err)
+			}
+			q.finishSegment(seq)
+			return true
+		}
+
+		// Relu à chaque tour : une fin de fichier n'est définitive que sur un segment clos
+		// avant la lecture.
+		_, sealed = q.segmentPosition(seq)
+	}
+}
+
+// segmentPosition retourne la position de lecture du segment seq et s'il est clos.
+func (q *Queue) segmentPosition(seq uint64) (int64, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if st := q.segments[seq]; st != nil {
+		return st.readOffset, st.sealed
+	}
+	return 0, true
+}
+
+// segmentSize retourne le nombre d'octets écrits dans le segment seq.
+func (q *Queue) segmentSize(seq uint64) int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if st := q.segments[seq]; st != nil {
+		return st.size
+	}
+	return 0
+}
+
+// markDispatched enregistre la transmission d'un événement du segment seq.
+func (q *Queue) markDispatched(seq uint64, offset int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if st := q.segments[seq]; st != nil {
+		st.readOffset = offset
+		st.pending++
+	}
+}
+
+// rotateIfIdle clôt le segment actif seq s'il a été entièrement transmis et qu'il est assez ancien.
+func (q *Queue) rotateIfIdle(seq uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	st := q.segments[seq]
+	if q.closed || seq != q.activeSeq || st.size == 0 || st.readOffset < st.size ||
+		time.Since(q.activeCreated) < segmentIdleRotation {
+		return
+	}
+	if err := q.rotateLocked(); err != nil {
+		q.logger.Error("Erreur lors de la rotation du segment", "segment", seq, "error", err)
+	}
+}
+
+// finishSegment marque le segment seq comme entièrement transmis, en ignorant une éventuelle fin
+// illisible, et le clôt s'il était encore actif.
+func (q *Queue) finishSegment(seq uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	st := q.segments[seq]
+	if st == nil {
+		return
+	}
+	if seq == q.activeSeq && !q.closed {
+		if err := q.rotateLocked(); err != nil {
+			q.logger.Error("Erreur lors de la rotation du segment", "segment", seq, "error", err)
+		}
+	}
+	st.readOffset = st.size
+	q.removeIfDoneLocked(seq)
+}
@@ -0,0 +1,265 @@
+package clickqueue
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"urlshortener/internal/models"
+)
+
+// testEvent retourne un événement identifiable par son LinkID.
+func testEvent(id uint) models.ClickEvent {
+	return models.ClickEvent{
+		LinkID:    id,
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+		UserAgent: "Mozilla/5.0",
+		IPAddress: "192.0.2.1",
+		RequestID: "req",
+	}
+}
+
+// openTestQueue ouvre une file dans dir et lance son dispatcher. La file est fermée en fin de test.
+func openTestQueue(t *testing.T, dir string, segmentMaxBytes int64) (*Queue, chan models.ClickEvent) {
+	t.Helper()
+	q, err := Open(Options{Dir: dir, SegmentMaxBytes: segmentMaxBytes})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	out := make(chan models.ClickEvent, 100)
+	q.Start(out)
+	return q, out
+}
+
+// receive attend n événements sur out.
+func receive(t *testing.T, out <-chan models.ClickEvent, n int) []models.ClickEvent {
+	t.Helper()
+	events := make([]models.ClickEvent, 0, n)
+	for len(events) < n {
+		select {
+		case event := <-out:
+			events = append(events, event)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d event(s), want %d", len(events), n)
+		}
+	}
+	return events
+}
+
+// expectNone vérifie qu'aucun événement supplémentaire n'est transmis.
+func expectNone(t *testing.T, out <-chan models.ClickEvent) {
+	t.Helper()
+	select {
+	case event := <-out:
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+// segmentExists indique si le fichier du segment seq est présent dans dir.
+func segmentExists(t *testing.T, dir string, seq uint64) bool {
+	t.Helper()
+	_, err := os.Stat(segmentPath(dir, seq))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("stat segment %d: %v", seq, err)
+	}
+	return err == nil
+}
+
+func mustEncode(t *testing.T, event models.ClickEvent) []byte {
+	t.Helper()
+	record, err := encodeRecord(event)
+	if err != nil {
+		t.Fatalf("encodeRecord: %v", err)
+	}
+	return record
+}
+
+func TestQueueRotationKeepsRecordsWhole(t *testing.T) {
+	recordSize := int64(len(mustEncode(t, testEvent(1))))
+
+	tests := []struct {
+		name            string
+		segmentMaxBytes int64
+		wantSegments    int // Segments créés pour 5 événements
+	}{
+		{"one record per segment", 1, 5},
+		{"rotation inside the next record", recordSize + recordSize/2, 5},
+		{"two records per segment", 2 * recordSize, 3},
+		{"single segment", 1 << 20, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q, out := openTestQueue(t, dir, tt.segmentMaxBytes)
+			for i := uint(1); i <= 5; i++ {
+				if err := q.Append(testEvent(i)); err != nil {
+					t.Fatalf("Append(%d): %v", i, err)
+				}
+			}
+
+			events := receive(t, out, 5)
+			for i, event := range events {
+				if event.LinkID != uint(i+1) || event.UserAgent != "Mozilla/5.0" {
+					t.Fatalf("event %d = %+v, want LinkID %d decoded intact", i, event, i+1)
+				}
+			}
+
+			seqs, err := listSegments(dir)
+			if err != nil {
+				t.Fatalf("listSegments: %v", err)
+			}
+			if len(seqs) != tt.wantSegments {
+				t.Fatalf("%d segment(s) on disk, want %d", len(seqs), tt.wantSegments)
+			}
+			// Un enregistrement n'est jamais coupé : chaque segment contient un nombre entier d'enregistrements.
+			for _, seq := range seqs {
+				info, err := os.Stat(segmentPath(dir, seq))
+				if err != nil {
+					t.Fatalf("stat segment %d: %v", seq, err)
+				}
+				if info.Size()%recordSize != 0 {
+					t.Fatalf("segment %d holds %d bytes, not a whole number of %d-byte records", seq, info.Size(), recordSize)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueIgnoresUnreadableTail(t *testing.T) {
+	valid := append(mustEncode(t, testEvent(1)), mustEncode(t, testEvent(2))...)
+	third := mustEncode(t, testEvent(3))
+
+	corrupted := append([]byte(nil), third...)
+	corrupted[len(corrupted)-2] ^= 0xff
+
+	zeroLength := append([]byte(nil), third...)
+	binary.LittleEndian.PutUint32(zeroLength[0:4], 0)
+
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{"truncated header", third[:recordHeaderSize-3]},
+		{"truncated payload", third[:len(third)-5]},
+		{"checksum mismatch", corrupted},
+		{"invalid length", zeroLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// Segment laissé par un arrêt brutal au milieu de l'écriture du troisième événement.
+			if err := os.WriteFile(segmentPath(dir, 1), append(append([]byte(nil), valid...), tt.tail...), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			q, out := openTestQueue(t, dir, 1<<20)
+			events := receive(t, out, 2)
+			expectNone(t, out)
+			if events[0].LinkID != 1 || events[1].LinkID != 2 {
+				t.Fatalf("replayed LinkIDs %d, %d, want 1, 2", events[0].LinkID, events[1].LinkID)
+			}
+
+			// Une fois ses événements valides acquittés, le segment est supprimé malgré sa fin illisible.
+			q.Ack(events)
+			if segmentExists(t, dir, 1) {
+				t.Fatal("segment with unreadable tail still present after ack")
+			}
+
+			// La file reste utilisable après la fin illisible.
+			if err := q.Append(testEvent(4)); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+			if event := receive(t, out, 1)[0]; event.LinkID != 4 {
+				t.Fatalf("LinkID = %d, want 4", event.LinkID)
+			}
+		})
+	}
+}
+
+func TestQueueReplaysUnackedEventsAfterReopen(t *testing.T) {
+	tests := []struct {
+		name       string
+		acked      int // Événements acquittés avant la fermeture, parmi 3
+		wantReplay int
+	}{
+		{"nothing acked", 0, 3},
+		// L'acquittement porte sur le segment : un segment partiellement acquitté est rejoué en entier.
+		{"partially acked", 1, 3},
+		{"all acked", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q, err := Open(Options{Dir: dir})
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			out := make(chan models.ClickEvent, 10)
+			q.Start(out)
+			for i := uint(1); i <= 3; i++ {
+				if err := q.Append(testEvent(i)); err != nil {
+					t.Fatalf("Append(%d): %v", i, err)
+				}
+			}
+			events := receive(t, out, 3)
+			q.Ack(events[:tt.acked])
+			if err := q.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			_, out = openTestQueue(t, dir, 0)
+			replayed := receive(t, out, tt.wantReplay)
+			expectNone(t, out)
+			for i, event := range replayed {
+				if event.LinkID != uint(i+1) {
+					t.Fatalf("replayed event %d has LinkID %d, want %d", i, event.LinkID, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueDeletesFullyAckedSegments(t *testing.T) {
+	tests := []struct {
+		name        string
+		ack         []uint // LinkIDs acquittés ; chaque événement est seul dans son segment
+		wantPresent map[uint64]bool
+	}{
+		{"nothing acked", nil, map[uint64]bool{1: true, 2: true}},
+		{"first acked", []uint{1}, map[uint64]bool{1: false, 2: true}},
+		{"second acked", []uint{2}, map[uint64]bool{1: true, 2: false}},
+		{"both acked", []uint{1, 2}, map[uint64]bool{1: false, 2: false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q, out := openTestQueue(t, dir, 1)
+			for i := uint(1); i <= 3; i++ {
+				if err := q.Append(testEvent(i)); err != nil {
+					t.Fatalf("Append(%d): %v", i, err)
+				}
+			}
+			events := receive(t, out, 3)
+
+			var acked []models.ClickEvent
+			for _, event := range events {
+				for _, id := range tt.ack {
+					if event.LinkID == id {
+						acked = append(acked, event)
+					}
+				}
+			}
+			q.Ack(acked)
+
+			// Le segment 3 est le segment actif : il n'est supprimé qu'une fois clos.
+			for seq, want := range tt.wantPresent {
+				if got := segmentExists(t, dir, seq); got != want {
+					t.Errorf("segment %d present = %v, want %v", seq, got, want)
+				}
+			}
+		})
+	}
+}
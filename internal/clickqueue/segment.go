@@ -0,0 +1,122 @@
+package clickqueue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"urlshortener/internal/models"
+)
+
+// Un segment est un fichier en ajout seul contenant une suite d'enregistrements :
+//
+//	[longueur uint32][crc32 uint32][événement JSON]
+//
+// Les entiers sont en little-endian et le CRC (IEEE) porte sur le JSON. Un enregistrement
+// incomplet ou dont le CRC ne correspond pas marque la fin exploitable du segment
+// (écriture interrompue par un arrêt brutal).
+const (
+	segmentExt       = ".seg"
+	recordHeaderSize = 8
+	maxRecordSize    = 1 << 20
+)
+
+// errCorruptRecord est retournée quand un enregistrement complet est illisible.
+var errCorruptRecord = errors.New("corrupt record")
+
+// segmentPath retourne le chemin du segment de numéro seq dans dir.
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%016d%s", seq, segmentExt))
+}
+
+// listSegments retourne, triés par ordre croissant, les numéros des segments présents dans dir.
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqs []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+// encodeRecord sérialise un événement au format d'enregistrement d'un segment.
+func encodeRecord(event models.ClickEvent) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > maxRecordSize {
+		return nil, fmt.Errorf("click event too large (%d bytes)", len(payload))
+	}
+
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderSize:], payload)
+	return record, nil
+}
+
+// readRecord lit l'enregistrement situé à offset et retourne l'événement et la taille de l'enregistrement.
+// Elle retourne io.EOF si l'enregistrement est absent ou incomplet, errCorruptRecord s'il est illisible.
+func readRecord(f *os.File, offset int64) (models.ClickEvent, int64, error) {
+	var event models.ClickEvent
+
+	var header [recordHeaderSize]byte
+	if n, err := f.ReadAt(header[:], offset); n < recordHeaderSize {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.EOF
+		}
+		return event, 0, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:4])
+	if length == 0 || length > maxRecordSize {
+		return event, 0, fmt.Errorf("%w: invalid length %d", errCorruptRecord, length)
+	}
+
+	payload := make([]byte, length)
+	if n, err := f.ReadAt(payload, offset+recordHeaderSize); n < len(payload) {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.EOF
+		}
+		return event, 0, err
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+		return event, 0, fmt.Errorf("%w: checksum mismatch", errCorruptRecord)
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return event, 0, fmt.Errorf("%w: %v", errCorruptRecord, err)
+	}
+	return event, recordHeaderSize + int64(length), nil
+}
+
+// syncDir force l'écriture sur disque des entrées du répertoire (création et suppression de segments).
+// Les erreurs sont ignorées : certains systèmes ne permettent pas de synchroniser un répertoire.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
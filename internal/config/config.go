@@ -2,7 +2,6 @@ package config
 
 import (
 	"fmt"
-	"log" // Pour logger les informations ou erreurs de chargement de config
 
 	"github.com/spf13/viper" // La bibliothèque pour la gestion de configuration
 )
@@ -13,20 +12,178 @@ type Config struct {
 	Server struct {
 		Port    int    `mapstructure:"port"`
 		BaseURL string `mapstructure:"base_url"`
+
+		ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
+
+		// Adresses ou plages CIDR des proxies dont les en-têtes X-Forwarded-For et X-Real-IP sont crus
+		// pour déterminer l'adresse du client. Vide : l'adresse de la connexion est toujours utilisée.
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	} `mapstructure:"server"`
 
+	Log struct {
+		Format string `mapstructure:"format"` // text ou json
+		Level  string `mapstructure:"level"`  // debug, info, warn ou error
+	} `mapstructure:"log"`
+
 	Database struct {
-		Name string `mapstructure:"name"`
+		Driver string `mapstructure:"driver"` // sqlite, postgres ou mysql
+		Name   string `mapstructure:"name"`   // Fichier SQLite, utilisé si dsn est vide
+		DSN    string `mapstructure:"dsn"`
+
+		MaxOpenConns           int `mapstructure:"max_open_conns"`
+		MaxIdleConns           int `mapstructure:"max_idle_conns"`
+		ConnMaxLifetimeSeconds int `mapstructure:"conn_max_lifetime_seconds"`
+		ConnMaxIdleTimeSeconds int `mapstructure:"conn_max_idle_time_seconds"`
+
+		SQLite struct {
+			JournalMode   string `mapstructure:"journal_mode"`
+			BusyTimeoutMs int    `mapstructure:"busy_timeout_ms"`
+		} `mapstructure:"sqlite"`
 	} `mapstructure:"database"`
 
 	Analytics struct {
-		BufferSize  int `mapstructure:"buffer_size"`
-		WorkerCount int `mapstructure:"worker_count"`
+		BufferSize      int `mapstructure:"buffer_size"`
+		WorkerCount     int `mapstructure:"worker_count"`
+		BatchSize       int `mapstructure:"batch_size"`
+		FlushIntervalMs int `mapstructure:"flush_interval_ms"`
+
+		Queue struct {
+			Enabled         bool   `mapstructure:"enabled"`
+			Dir             string `mapstructure:"dir"`
+			SyncIntervalMs  int    `mapstructure:"sync_interval_ms"`
+			SegmentMaxBytes int64  `mapstructure:"segment_max_bytes"`
+		} `mapstructure:"queue"`
 	} `mapstructure:"analytics"`
 
 	Monitor struct {
-		IntervalMinutes int `mapstructure:"interval_minutes"`
+		IntervalMinutes       int   `mapstructure:"interval_minutes"`
+		TimeoutSeconds        int   `mapstructure:"timeout_seconds"`
+		MaxRedirects          int   `mapstructure:"max_redirects"`
+		MaxResponseBytes      int64 `mapstructure:"max_response_bytes"`
+		AllowPrivateAddresses bool  `mapstructure:"allow_private_addresses"`
+
+		WorkerCount        int     `mapstructure:"worker_count"`
+		PerHostConcurrency int     `mapstructure:"per_host_concurrency"`
+		ChecksPerSecond    float64 `mapstructure:"checks_per_second"`
+		JitterPercent      int     `mapstructure:"jitter_percent"`
+		HistoryDays        int     `mapstructure:"history_days"`
+		ContentFingerprint bool    `mapstructure:"content_fingerprint"`
 	} `mapstructure:"monitor"`
+
+	Notifications struct {
+		CooldownMinutes int                     `mapstructure:"cooldown_minutes"`
+		Webhooks        []WebhookNotifierConfig `mapstructure:"webhooks"`
+		Slack           []SlackNotifierConfig   `mapstructure:"slack"`
+		Email           []EmailNotifierConfig   `mapstructure:"email"`
+		Commands        []CommandNotifierConfig `mapstructure:"commands"`
+		Routes          []NotificationRoute     `mapstructure:"routes"`
+	} `mapstructure:"notifications"`
+
+	Bots struct {
+		PatternsFile  string   `mapstructure:"patterns_file"`
+		ExtraPatterns []string `mapstructure:"extra_patterns"`
+	} `mapstructure:"bots"`
+
+	Privacy struct {
+		VisitorSalt        string `mapstructure:"visitor_salt"`
+		DropRawIdentifiers bool   `mapstructure:"drop_raw_identifiers"`
+		AnonymizeIP        bool   `mapstructure:"anonymize_ip"`
+	} `mapstructure:"privacy"`
+
+	Auth struct {
+		Enabled bool `mapstructure:"enabled"`
+	} `mapstructure:"auth"`
+
+	RateLimit struct {
+		Enabled                bool `mapstructure:"enabled"`
+		IdleTimeoutSeconds     int  `mapstructure:"idle_timeout_seconds"`
+		CleanupIntervalSeconds int  `mapstructure:"cleanup_interval_seconds"`
+
+		API      RateLimitRule `mapstructure:"api"`
+		Create   RateLimitRule `mapstructure:"create"`
+		Stats    RateLimitRule `mapstructure:"stats"`
+		Redirect RateLimitRule `mapstructure:"redirect"`
+	} `mapstructure:"rate_limit"`
+
+	URLPolicy struct {
+		AllowedSchemes         []string `mapstructure:"allowed_schemes"`
+		BlockPrivateAddresses  bool     `mapstructure:"block_private_addresses"`
+		DNSTimeoutMs           int      `mapstructure:"dns_timeout_ms"`
+		BlocklistFile          string   `mapstructure:"blocklist_file"`
+		BlocklistReloadSeconds int      `mapstructure:"blocklist_reload_seconds"`
+	} `mapstructure:"url_policy"`
+
+	Cache struct {
+		Enabled            bool `mapstructure:"enabled"`
+		Size               int  `mapstructure:"size"`
+		TTLSeconds         int  `mapstructure:"ttl_seconds"`
+		NegativeTTLSeconds int  `mapstructure:"negative_ttl_seconds"`
+	} `mapstructure:"cache"`
+
+	Retention struct {
+		ClickDays     int    `mapstructure:"click_days"`
+		Mode          string `mapstructure:"mode"`
+		IntervalHours int    `mapstructure:"interval_hours"`
+	} `mapstructure:"retention"`
+
+	Metrics struct {
+		Enabled   bool `mapstructure:"enabled"`
+		AdminPort int  `mapstructure:"admin_port"`
+	} `mapstructure:"metrics"`
+
+	Fallback struct {
+		DefaultURL        string `mapstructure:"default_url"`
+		AfterFailedChecks int    `mapstructure:"after_failed_checks"`
+	} `mapstructure:"fallback"`
+
+	Expiration struct {
+		FallbackURL  string `mapstructure:"fallback_url"`
+		FallbackPage string `mapstructure:"fallback_page"`
+	} `mapstructure:"expiration"`
+}
+
+// RateLimitRule est la limite de débit d'une famille de routes, par client.
+type RateLimitRule struct {
+	RequestsPerMinute int `mapstructure:"requests_per_minute"` // 0 = pas de limite
+	Burst             int `mapstructure:"burst"`
+}
+
+// WebhookNotifierConfig configure un webhook JSON générique signé par HMAC.
+type WebhookNotifierConfig struct {
+	Name   string `mapstructure:"name"`
+	URL    string `mapstructure:"url"`
+	Secret string `mapstructure:"secret"`
+}
+
+// SlackNotifierConfig configure un webhook entrant compatible Slack.
+type SlackNotifierConfig struct {
+	Name       string `mapstructure:"name"`
+	WebhookURL string `mapstructure:"webhook_url"`
+}
+
+// EmailNotifierConfig configure l'envoi des notifications par e-mail.
+type EmailNotifierConfig struct {
+	Name     string   `mapstructure:"name"`
+	Host     string   `mapstructure:"host"`
+	Port     int      `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+// CommandNotifierConfig configure une commande locale exécutée pour chaque notification.
+type CommandNotifierConfig struct {
+	Name           string   `mapstructure:"name"`
+	Command        []string `mapstructure:"command"`
+	TimeoutSeconds int      `mapstructure:"timeout_seconds"`
+}
+
+// NotificationRoute associe des notifiers aux liens d'un propriétaire ou portant une étiquette.
+type NotificationRoute struct {
+	Notifiers []string `mapstructure:"notifiers"`
+	Owners    []uint   `mapstructure:"owners"`
+	Tags      []string `mapstructure:"tags"`
 }
 
 // LoadConfig charge la configuration de l'application en utilisant Viper.
@@ -46,21 +203,108 @@ func LoadConfig() (*Config, error) {
 	// Server defaults
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.shutdown_timeout_seconds", 15)
+	viper.SetDefault("server.trusted_proxies", []string{})
+
+	// Log defaults
+	viper.SetDefault("log.format", "text")
+	viper.SetDefault("log.level", "info")
 
 	// Database defaults
+	viper.SetDefault("database.driver", "sqlite")
 	viper.SetDefault("database.name", "url_shortener.db")
+	viper.SetDefault("database.dsn", "")
+	viper.SetDefault("database.max_open_conns", 25)
+	viper.SetDefault("database.max_idle_conns", 5)
+	viper.SetDefault("database.conn_max_lifetime_seconds", 1800)
+	viper.SetDefault("database.conn_max_idle_time_seconds", 300)
+	viper.SetDefault("database.sqlite.journal_mode", "WAL")
+	viper.SetDefault("database.sqlite.busy_timeout_ms", 5000)
 
 	// Analytics defaults
 	viper.SetDefault("analytics.buffer_size", 1000)
 	viper.SetDefault("analytics.worker_count", 5)
+	viper.SetDefault("analytics.batch_size", 100)
+	viper.SetDefault("analytics.flush_interval_ms", 1000)
+	viper.SetDefault("analytics.queue.enabled", false)
+	viper.SetDefault("analytics.queue.dir", "data/click-queue")
+	viper.SetDefault("analytics.queue.sync_interval_ms", 50)
+	viper.SetDefault("analytics.queue.segment_max_bytes", 4<<20)
 
 	// Monitor defaults
 	viper.SetDefault("monitor.interval_minutes", 5)
+	viper.SetDefault("monitor.timeout_seconds", 5)
+	viper.SetDefault("monitor.max_redirects", 5)
+	viper.SetDefault("monitor.max_response_bytes", 1<<20)
+	viper.SetDefault("monitor.allow_private_addresses", false)
+	viper.SetDefault("monitor.worker_count", 10)
+	viper.SetDefault("monitor.per_host_concurrency", 2)
+	viper.SetDefault("monitor.checks_per_second", 20)
+	viper.SetDefault("monitor.jitter_percent", 50)
+	viper.SetDefault("monitor.history_days", 30)
+	viper.SetDefault("monitor.content_fingerprint", false)
+
+	// Notifications defaults (aucun notifier : changements d'état seulement journalisés)
+	viper.SetDefault("notifications.cooldown_minutes", 15)
+
+	// Bots defaults (liste de motifs intégrée au binaire)
+	viper.SetDefault("bots.patterns_file", "")
+	viper.SetDefault("bots.extra_patterns", []string{})
+
+	// Privacy defaults (sel aléatoire à chaque démarrage si non configuré)
+	viper.SetDefault("privacy.visitor_salt", "")
+	viper.SetDefault("privacy.drop_raw_identifiers", false)
+	viper.SetDefault("privacy.anonymize_ip", false)
+
+	// Auth defaults
+	viper.SetDefault("auth.enabled", true)
+
+	// Rate limit defaults
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.idle_timeout_seconds", 600)
+	viper.SetDefault("rate_limit.cleanup_interval_seconds", 60)
+	viper.SetDefault("rate_limit.api.requests_per_minute", 600)
+	viper.SetDefault("rate_limit.api.burst", 100)
+	viper.SetDefault("rate_limit.create.requests_per_minute", 30)
+	viper.SetDefault("rate_limit.create.burst", 10)
+	viper.SetDefault("rate_limit.stats.requests_per_minute", 120)
+	viper.SetDefault("rate_limit.stats.burst", 30)
+	viper.SetDefault("rate_limit.redirect.requests_per_minute", 600)
+	viper.SetDefault("rate_limit.redirect.burst", 100)
+
+	// URL policy defaults
+	viper.SetDefault("url_policy.allowed_schemes", []string{"http", "https"})
+	viper.SetDefault("url_policy.block_private_addresses", true)
+	viper.SetDefault("url_policy.dns_timeout_ms", 2000)
+	viper.SetDefault("url_policy.blocklist_file", "")
+	viper.SetDefault("url_policy.blocklist_reload_seconds", 30)
+
+	// Cache defaults
+	viper.SetDefault("cache.enabled", true)
+	viper.SetDefault("cache.size", 10000)
+	viper.SetDefault("cache.ttl_seconds", 60)
+	viper.SetDefault("cache.negative_ttl_seconds", 10)
+
+	// Retention defaults (conservation illimitée)
+	viper.SetDefault("retention.click_days", 0)
+	viper.SetDefault("retention.mode", "delete")
+	viper.SetDefault("retention.interval_hours", 24)
+
+	// Metrics defaults (/metrics servi uniquement sur le port d'administration)
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.admin_port", 9090)
+
+	// Fallback defaults (pas d'URL de repli globale)
+	viper.SetDefault("fallback.default_url", "")
+	viper.SetDefault("fallback.after_failed_checks", 3)
+
+	// Expiration defaults (chaînes vides : simple réponse JSON 410)
+	viper.SetDefault("expiration.fallback_url", "")
+	viper.SetDefault("expiration.fallback_page", "")
 
 	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Printf("Aucun fichier de configuration trouvé, utilisation des valeurs par défaut")
-		} else {
+		// Sans fichier de configuration, les valeurs par défaut s'appliquent.
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, fmt.Errorf("Erreur lors de la lecture du fichier de configuration : %v", err)
 		}
 	}
@@ -69,9 +313,21 @@ func LoadConfig() (*Config, error) {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("Impossible de décoder la configuration : %v", err)
 	}
-
-	log.Printf("Configuration loaded: Server Port=%d, DB Name=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
-		cfg.Server.Port, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("Configuration invalide : %v", err)
+	}
 
 	return &cfg, nil
 }
+
+// validate vérifie les intervalles des tâches périodiques lancées par le serveur :
+// un intervalle nul ou négatif les empêcherait de démarrer.
+func (c *Config) validate() error {
+	if c.Monitor.IntervalMinutes <= 0 {
+		return fmt.Errorf("monitor.interval_minutes doit être strictement positif (valeur : %d)", c.Monitor.IntervalMinutes)
+	}
+	if c.Retention.ClickDays > 0 && c.Retention.IntervalHours <= 0 {
+		return fmt.Errorf("retention.interval_hours doit être strictement positif quand retention.click_days est défini (valeur : %d)", c.Retention.IntervalHours)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package database ouvre la connexion GORM décrite par la configuration. C'est l'unique point
+// d'ouverture de la base de données, partagé par le serveur et toutes les commandes de la CLI.
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"urlshortener/internal/config"
+	"urlshortener/internal/logging"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Pilotes supportés pour database.driver.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Open ouvre la base de données configurée et applique les réglages du pool de connexions.
+// Les erreurs des pilotes sont traduites en erreurs GORM (ex: gorm.ErrDuplicatedKey) et les
+// journaux de GORM passent par slog.
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	dialector, err := newDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true, Logger: logging.GormLogger{}})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	pool := cfg.Database
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(pool.ConnMaxLifetimeSeconds) * time.Second)
+	sqlDB.SetConnMaxIdleTime(time.Duration(pool.ConnMaxIdleTimeSeconds) * time.Second)
+
+	return db, nil
+}
+
+// newDialector retourne le dialecte GORM correspondant à database.driver.
+func newDialector(cfg *config.Config) (gorm.Dialector, error) {
+	switch driver := strings.ToLower(cfg.Database.Driver); driver {
+	case "", DriverSQLite:
+		return sqlite.Open(sqliteDSN(cfg)), nil
+	case DriverPostgres:
+		if cfg.Database.DSN == "" {
+			return nil, fmt.Errorf("database.dsn is required for driver %q", driver)
+		}
+		return postgres.Open(cfg.Database.DSN), nil
+	case DriverMySQL:
+		if cfg.Database.DSN == "" {
+			return nil, fmt.Errorf("database.dsn is required for driver %q", driver)
+		}
+		return mysql.Open(mysqlDSN(cfg.Database.DSN)), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q (expected sqlite, postgres or mysql)", cfg.Database.Driver)
+	}
+}
+
+// sqliteDSN construit la chaîne de connexion SQLite à partir de database.dsn, ou à défaut de
+// database.name, en ajoutant le mode de journalisation et le délai d'attente sur verrou configurés
+// lorsqu'ils ne sont pas déjà précisés.
+func sqliteDSN(cfg *config.Config) string {
+	dsn := cfg.Database.DSN
+	if dsn == "" {
+		dsn = cfg.Database.Name
+	}
+
+	params := url.Values{}
+	if mode := cfg.Database.SQLite.JournalMode; mode != "" && !strings.Contains(dsn, "_journal_mode=") {
+		params.Set("_journal_mode", mode)
+	}
+	if timeout := cfg.Database.SQLite.BusyTimeoutMs; timeout > 0 && !strings.Contains(dsn, "_busy_timeout=") {
+		params.Set("_busy_timeout", strconv.Itoa(timeout))
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + params.Encode()
+}
+
+// mysqlDSN active parseTime, sans lequel les colonnes DATETIME ne peuvent pas être lues en time.Time.
+func mysqlDSN(dsn string) string {
+	if strings.Contains(dsn, "parseTime=") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&parseTime=true"
+	}
+	return dsn + "?parseTime=true"
+}
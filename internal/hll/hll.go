@@ -0,0 +1,59 @@
+package hll
+
+import (
+	"hash/maphash"
+	"math"
+	"math/bits"
+)
+
+// precision est le nombre de bits utilisés pour choisir un registre (2^14 registres, soit 16 Ko).
+// L'erreur standard attendue est d'environ 1,04/sqrt(2^14) ≈ 0,8 %.
+const precision = 14
+
+const registerCount = 1 << precision
+
+// Sketch est un compteur approximatif d'éléments distincts (HyperLogLog).
+// Sa mémoire est fixe quel que soit le nombre d'éléments ajoutés.
+// Un Sketch n'est pas sûr pour un usage concurrent.
+type Sketch struct {
+	seed      maphash.Seed
+	registers [registerCount]uint8
+}
+
+// New crée un Sketch vide.
+func New() *Sketch {
+	return &Sketch{seed: maphash.MakeSeed()}
+}
+
+// Add ajoute un élément au Sketch.
+func (s *Sketch) Add(value string) {
+	h := maphash.String(s.seed, value)
+	idx := h >> (64 - precision)
+	// Rang du premier bit à 1 dans les bits restants (1 si le premier bit est à 1).
+	rank := uint8(bits.LeadingZeros64(h<<precision|1<<(precision-1)) + 1)
+	if rank > s.registers[idx] {
+		s.registers[idx] = rank
+	}
+}
+
+// Count retourne l'estimation du nombre d'éléments distincts ajoutés.
+func (s *Sketch) Count() int {
+	m := float64(registerCount)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	sum := 0.0
+	zeros := 0
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha * m * m / sum
+
+	// Correction pour les petites cardinalités (comptage linéaire).
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(estimate))
+}
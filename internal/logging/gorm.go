@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold est la durée au-delà de laquelle une requête SQL est journalisée en Warn.
+const slowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger transmet les journaux de GORM à slog : requêtes en erreur en Error, requêtes lentes
+// en Warn et toutes les requêtes en Debug. gorm.ErrRecordNotFound n'est pas une erreur ici,
+// les repositories le traitent comme un résultat attendu.
+type GormLogger struct{}
+
+// LogMode est ignoré : le niveau est celui du logger slog par défaut.
+func (l GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+}
+
+func (GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+}
+
+func (GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+}
+
+func (GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	msg := "Requête SQL"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "Échec d'une requête SQL"
+	case elapsed > slowQueryThreshold:
+		level, msg = slog.LevelWarn, "Requête SQL lente"
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("component", "gorm"),
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	slog.LogAttrs(ctx, level, msg, attrs...)
+}
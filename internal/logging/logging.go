@@ -0,0 +1,88 @@
+// Package logging configure la journalisation structurée (log/slog) du serveur, des workers et
+// de la CLI : format texte ou JSON, niveau minimal, et identifiant de requête ajouté à chaque
+// ligne journalisée avec le contexte d'une requête HTTP.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Formats de sortie supportés pour log.format.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// RequestIDKey est le nom de l'attribut portant l'identifiant de requête.
+const RequestIDKey = "request_id"
+
+// Setup installe le logger par défaut de slog, écrivant sur la sortie d'erreur au format
+// 'format' (text ou json) à partir du niveau 'level' (debug, info, warn ou error).
+// Les appels restants au package log sont redirigés vers ce logger.
+func Setup(format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q (expected debug, info, warn or error)", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid log format %q (expected text or json)", format)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	// slog.SetDefault redirige le package log vers le handler au niveau Info : on retire le
+	// préfixe de date, déjà ajouté par le handler.
+	log.SetFlags(0)
+	return nil
+}
+
+// Fatal journalise 'msg' au niveau Error puis termine le programme, à la manière de log.Fatal.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type requestIDContextKey struct{}
+
+// WithRequestID retourne un contexte portant l'identifiant de requête 'id'.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestID retourne l'identifiant de requête porté par ctx, ou une chaîne vide.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// contextHandler ajoute aux enregistrements l'identifiant de requête porté par leur contexte.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Métriques du service, enregistrées dans Default.
+var (
+	// HTTPRequests compte les requêtes par méthode, route (motif Gin, "unmatched" si aucune) et code de statut.
+	HTTPRequests = Default.NewCounterVec("urlshortener_http_requests_total",
+		"Requêtes HTTP traitées, par méthode, route et code de statut.", "method", "route", "status")
+	// HTTPRequestDuration mesure la durée de traitement des requêtes par méthode et route.
+	HTTPRequestDuration = Default.NewHistogramVec("urlshortener_http_request_duration_seconds",
+		"Durée de traitement des requêtes HTTP, par méthode et route.", DefBuckets, "method", "route")
+
+	// Redirects compte les redirections par issue (voir les constantes Redirect*).
+	Redirects = Default.NewCounterVec("urlshortener_redirects_total",
+		"Requêtes de redirection, par issue (found, fallback, not_found, gone, invalid, error).", "outcome")
+
+	// ClickEventsDropped compte les événements de clic perdus avant d'atteindre les workers
+	// (channel plein ou écriture impossible dans la file durable).
+	ClickEventsDropped = Default.NewCounter("urlshortener_click_events_dropped_total",
+		"Événements de clic perdus avant leur traitement par les workers.")
+	// ClickInsertDuration mesure la durée des insertions de lots de clics.
+	ClickInsertDuration = Default.NewHistogram("urlshortener_click_insert_duration_seconds",
+		"Durée d'insertion d'un lot de clics en base.", DefBuckets)
+	// ClickBatchSize mesure le nombre de clics des lots insérés.
+	ClickBatchSize = Default.NewHistogram("urlshortener_click_batch_size",
+		"Nombre de clics par lot inséré.", []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000})
+	// ClickInsertErrors compte les lots de clics dont l'insertion a échoué.
+	ClickInsertErrors = Default.NewCounter("urlshortener_click_insert_errors_total",
+		"Lots de clics dont l'insertion en base a échoué.")
+
+	// MonitorChecks compte les vérifications du moniteur par état (up, down, content_changed).
+	MonitorChecks = Default.NewCounterVec("urlshortener_monitor_checks_total",
+		"Vérifications des URLs longues, par état constaté.", "state")
+	// MonitorCheckDuration mesure la durée des vérifications d'URL.
+	MonitorCheckDuration = Default.NewHistogram("urlshortener_monitor_check_duration_seconds",
+		"Durée d'une vérification d'URL longue.", DefBuckets)
+	// MonitorPassDuration mesure la durée des passes complètes du moniteur.
+	MonitorPassDuration = Default.NewHistogram("urlshortener_monitor_pass_duration_seconds",
+		"Durée d'une passe de vérification de toutes les URLs longues.", []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800})
+)
+
+// Issues d'une requête de redirection (étiquette outcome de Redirects).
+const (
+	RedirectFound    = "found"     // Redirection vers l'URL longue
+	RedirectFallback = "fallback"  // Redirection vers une URL de repli
+	RedirectNotFound = "not_found" // 404
+	RedirectGone     = "gone"      // 410, lien expiré
+	RedirectInvalid  = "invalid"   // 400
+	RedirectError    = "error"     // 500
+)
+
+// RegisterDBStats enregistre dans Default les statistiques du pool de connexions de db.
+func RegisterDBStats(db *sql.DB) {
+	gauges := []struct {
+		name, help string
+		value      func(sql.DBStats) float64
+	}{
+		{"urlshortener_db_max_open_connections", "Nombre maximum de connexions ouvertes (0 = illimité).",
+			func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+		{"urlshortener_db_open_connections", "Connexions ouvertes, utilisées ou inactives.",
+			func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+		{"urlshortener_db_in_use_connections", "Connexions en cours d'utilisation.",
+			func(s sql.DBStats) float64 { return float64(s.InUse) }},
+		{"urlshortener_db_idle_connections", "Connexions inactives.",
+			func(s sql.DBStats) float64 { return float64(s.Idle) }},
+	}
+	for _, g := range gauges {
+		value := g.value
+		Default.NewGaugeFunc(g.name, g.help, func() float64 { return value(db.Stats()) })
+	}
+
+	counters := []struct {
+		name, help string
+		value      func(sql.DBStats) float64
+	}{
+		{"urlshortener_db_wait_count_total", "Attentes d'une connexion libre.",
+			func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+		{"urlshortener_db_wait_duration_seconds_total", "Durée cumulée des attentes d'une connexion libre.",
+			func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+		{"urlshortener_db_closed_max_idle_total", "Connexions fermées faute de place parmi les connexions inactives.",
+			func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+		{"urlshortener_db_closed_max_lifetime_total", "Connexions fermées pour avoir atteint leur durée de vie maximale.",
+			func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+	}
+	for _, c := range counters {
+		value := c.value
+		Default.NewCounterFunc(c.name, c.help, func() float64 { return value(db.Stats()) })
+	}
+}
+
+// ObserveSince ajoute à h la durée écoulée depuis 'started', en secondes.
+func ObserveSince(h *Histogram, started time.Time) {
+	h.Observe(time.Since(started).Seconds())
+}
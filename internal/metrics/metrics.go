@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets sont les bornes par défaut des histogrammes de durée, en secondes.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// atomicFloat est un float64 modifiable de façon concurrente sans verrou.
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// Counter est une valeur qui ne fait que croître.
+type Counter struct {
+	value atomicFloat
+}
+
+// Inc incrémente le compteur de 1.
+func (c *Counter) Inc() { c.value.add(1) }
+
+// Add ajoute v, qui doit être positif, au compteur.
+func (c *Counter) Add(v float64) { c.value.add(v) }
+
+// Value retourne la valeur du compteur.
+func (c *Counter) Value() float64 { return c.value.load() }
+
+// Histogram répartit des observations (durées, tailles...) dans des intervalles cumulatifs.
+type Histogram struct {
+	upperBounds []float64
+	counts      []atomic.Uint64 // Une case par borne, plus une pour +Inf
+	count       atomic.Uint64
+	sum         atomicFloat
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: histogram buckets must be sorted: %v", buckets))
+	}
+	return &Histogram{
+		upperBounds: buckets,
+		counts:      make([]atomic.Uint64, len(buckets)+1),
+	}
+}
+
+// Observe ajoute une observation à l'histogramme.
+func (h *Histogram) Observe(v float64) {
+	// Première borne supérieure ou égale à v ; len(upperBounds) correspond à +Inf.
+	h.counts[sort.SearchFloat64s(h.upperBounds, v)].Add(1)
+	h.count.Add(1)
+	h.sum.add(v)
+}
+
+// write écrit les séries _bucket, _sum et _count de l'histogramme.
+func (h *Histogram) write(w io.Writer, name, labels string) {
+	// L'étiquette le s'ajoute aux étiquettes de la série.
+	bucketLabels := func(le string) string {
+		if labels == "" {
+			return `{le="` + le + `"}`
+		}
+		return strings.TrimSuffix(labels, "}") + `,le="` + le + `"}`
+	}
+	var cumulative uint64
+	for i, bound := range h.upperBounds {
+		cumulative += h.counts[i].Load()
+		writeSample(w, name+"_bucket", bucketLabels(formatValue(bound)), float64(cumulative))
+	}
+	cumulative += h.counts[len(h.upperBounds)].Load()
+	writeSample(w, name+"_bucket", bucketLabels("+Inf"), float64(cumulative))
+	writeSample(w, name+"_sum", labels, h.sum.load())
+	writeSample(w, name+"_count", labels, float64(cumulative))
+}
+
+// vec décline une métrique selon les valeurs de ses étiquettes.
+type vec[T any] struct {
+	labelNames []string
+	newMetric  func() *T
+
+	mu      sync.RWMutex
+	metrics map[string]*vecEntry[T] // Clé : valeurs des étiquettes jointes
+}
+
+type vecEntry[T any] struct {
+	labels string // Étiquettes formatées, voir formatLabels
+	metric *T
+}
+
+func newVec[T any](labelNames []string, newMetric func() *T) vec[T] {
+	return vec[T]{
+		labelNames: labelNames,
+		newMetric:  newMetric,
+		metrics:    make(map[string]*vecEntry[T]),
+	}
+}
+
+// with retourne la métrique des valeurs d'étiquettes 'values', créée au premier appel.
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: expected %d label values, got %d", len(v.labelNames), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	entry, ok := v.metrics[key]
+	v.mu.RUnlock()
+	if ok {
+		return entry.metric
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if entry, ok := v.metrics[key]; ok {
+		return entry.metric
+	}
+	entry = &vecEntry[T]{labels: formatLabels(v.labelNames, values), metric: v.newMetric()}
+	v.metrics[key] = entry
+	return entry.metric
+}
+
+// each appelle fn pour chaque série, triées par étiquettes.
+func (v *vec[T]) each(fn func(labels string, metric *T)) {
+	v.mu.RLock()
+	entries := make([]*vecEntry[T], 0, len(v.metrics))
+	for _, entry := range v.metrics {
+		entries = append(entries, entry)
+	}
+	v.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].labels < entries[j].labels })
+	for _, entry := range entries {
+		fn(entry.labels, entry.metric)
+	}
+}
+
+// CounterVec est un compteur décliné selon des étiquettes.
+type CounterVec struct {
+	vec[Counter]
+}
+
+// WithLabelValues retourne le compteur des valeurs d'étiquettes données, dans l'ordre de déclaration.
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	return v.with(values)
+}
+
+// HistogramVec est un histogramme décliné selon des étiquettes.
+type HistogramVec struct {
+	vec[Histogram]
+}
+
+// WithLabelValues retourne l'histogramme des valeurs d'étiquettes données, dans l'ordre de déclaration.
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return v.with(values)
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry regroupe des métriques et les exporte au format texte de Prometheus (version 0.0.4).
+// Les noms de métriques sont uniques : en enregistrer un deux fois est une erreur de programmation.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// family est une métrique exportée : son nom, sa description, son type et ses séries.
+type family struct {
+	name  string
+	help  string
+	kind  string                         // counter, gauge ou histogram
+	write func(w io.Writer, name string) // Écrit les lignes des séries de la métrique
+}
+
+// Default est le registre des métriques du service, exposé par /metrics.
+var Default = NewRegistry()
+
+// NewRegistry crée un registre vide.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// register ajoute une métrique au registre.
+func (r *Registry) register(f *family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.families[f.name]; exists {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", f.name))
+	}
+	r.families[f.name] = f
+}
+
+// NewCounter enregistre un compteur sans étiquette.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).WithLabelValues()
+}
+
+// NewCounterVec enregistre un compteur décliné selon les étiquettes labelNames.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	v := &CounterVec{newVec(labelNames, func() *Counter { return &Counter{} })}
+	r.register(&family{name: name, help: help, kind: "counter", write: func(w io.Writer, name string) {
+		v.each(func(labels string, c *Counter) {
+			writeSample(w, name, labels, c.Value())
+		})
+	}})
+	return v
+}
+
+// NewHistogram enregistre un histogramme sans étiquette, de bornes supérieures 'buckets' (croissantes).
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).WithLabelValues()
+}
+
+// NewHistogramVec enregistre un histogramme décliné selon les étiquettes labelNames.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	v := &HistogramVec{newVec(labelNames, func() *Histogram { return newHistogram(buckets) })}
+	r.register(&family{name: name, help: help, kind: "histogram", write: func(w io.Writer, name string) {
+		v.each(func(labels string, h *Histogram) {
+			h.write(w, name, labels)
+		})
+	}})
+	return v
+}
+
+// NewGaugeFunc enregistre une jauge dont la valeur est lue par fn à chaque export.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&family{name: name, help: help, kind: "gauge", write: func(w io.Writer, name string) {
+		writeSample(w, name, "", fn())
+	}})
+}
+
+// NewCounterFunc enregistre un compteur dont la valeur, croissante, est lue par fn à chaque export.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&family{name: name, help: help, kind: "counter", write: func(w io.Writer, name string) {
+		writeSample(w, name, "", fn())
+	}})
+}
+
+// WriteText écrit toutes les métriques au format texte de Prometheus, triées par nom.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.kind)
+		f.write(bw, f.name)
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP implémente http.Handler : le registre peut être servi directement sur /metrics.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+// writeSample écrit une ligne "nom{étiquettes} valeur".
+func writeSample(w io.Writer, name, labels string, value float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(value))
+}
+
+// formatValue formate une valeur selon la syntaxe de Prometheus (+Inf, -Inf, NaN).
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatLabels retourne les étiquettes au format {nom="valeur",...}, ou une chaîne vide sans étiquette.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelValueEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeHelp échappe le texte d'une ligne HELP.
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
@@ -0,0 +1,321 @@
+// Package migrations gère l'évolution versionnée du schéma de la base de données.
+// Les migrations sont des fichiers SQL numérotés, écrits pour chaque pilote supporté et
+// embarqués dans le binaire ; la table schema_migrations enregistre celles déjà appliquées.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Fichiers de migration, sous la forme sql/<pilote>/<version>_<nom>.<up|down>.sql.
+//
+//go:embed sql
+var files embed.FS
+
+// SourceDir est l'emplacement des fichiers de migration dans le dépôt, utilisé par Create.
+const SourceDir = "internal/migrations/sql"
+
+// fileNamePattern décrit le nom d'un fichier de migration.
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration est une évolution du schéma et son script d'annulation.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status décrit l'état d'une migration dans une base de données.
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // nil si la migration n'est pas appliquée
+	Missing   bool       // Appliquée en base mais absente de ce binaire
+}
+
+// schemaMigration est une ligne de la table schema_migrations.
+type schemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+// TableName fixe le nom de la table de suivi des migrations.
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applique et annule les migrations du pilote de la base de données.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New charge les migrations correspondant au pilote de db et crée la table schema_migrations si besoin.
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("error creating schema_migrations table: %w", err)
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// load lit les migrations embarquées d'un pilote, triées par version.
+func load(driver string) ([]Migration, error) {
+	dir := path.Join("sql", driver)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+
+		content, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// applied retourne les migrations enregistrées dans schema_migrations, par version croissante.
+func (m *Migrator) applied() ([]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	return rows, nil
+}
+
+// Up applique, dans l'ordre, toutes les migrations qui ne le sont pas encore, chacune dans sa propre
+// transaction. Elle s'arrête à la première erreur et retourne les migrations appliquées jusque-là.
+// Avec MySQL, les instructions DDL valident implicitement la transaction en cours.
+func (m *Migrator) Up() ([]Migration, error) {
+	rows, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[int64]bool, len(rows))
+	for _, row := range rows {
+		done[row.Version] = true
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		if done[migration.Version] {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Down annule les n dernières migrations appliquées, de la plus récente à la plus ancienne,
+// chacune dans sa propre transaction.
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	rows, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	var reverted []Migration
+	for i := len(rows) - 1; i >= 0 && len(reverted) < n; i-- {
+		migration, ok := known[rows[i].Version]
+		if !ok {
+			return reverted, fmt.Errorf("migration %d_%s is not known by this binary", rows[i].Version, rows[i].Name)
+		}
+		if migration.Down == "" {
+			return reverted, fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Down); err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("rollback of migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// Status retourne l'état de chaque migration connue, ainsi que des migrations appliquées
+// en base mais absentes de ce binaire, par version croissante.
+func (m *Migrator) Status() ([]Status, error) {
+	rows, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		appliedAt[row.Version] = row
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(appliedAt, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range appliedAt {
+		statuses = append(statuses, Status{Version: row.Version, Name: row.Name, AppliedAt: &row.AppliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// execScript exécute les instructions d'un script de migration une par une.
+func execScript(tx *gorm.DB, script string) error {
+	for _, statement := range splitStatements(script) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements découpe un script SQL en instructions. Une instruction se termine par un
+// point-virgule en fin de ligne ; les lignes de commentaire ("--") sont ignorées.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// namePattern décrit les caractères autorisés dans le nom d'une nouvelle migration.
+var namePattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create ajoute une migration vide nommée 'name' pour chaque pilote présent dans dir (en général
+// SourceDir), avec le numéro suivant la plus grande version existante. Elle retourne les fichiers créés.
+// Les fichiers étant embarqués à la compilation, le binaire doit être reconstruit pour les appliquer.
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(namePattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("invalid migration name")
+	}
+
+	drivers, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations directory: %w", err)
+	}
+
+	var next int64 = 1
+	var driverDirs []string
+	for _, driver := range drivers {
+		if !driver.IsDir() {
+			continue
+		}
+		driverDir := filepath.Join(dir, driver.Name())
+		driverDirs = append(driverDirs, driverDir)
+
+		entries, err := os.ReadDir(driverDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if match := fileNamePattern.FindStringSubmatch(entry.Name()); match != nil {
+				if version, _ := strconv.ParseInt(match[1], 10, 64); version >= next {
+					next = version + 1
+				}
+			}
+		}
+	}
+	if len(driverDirs) == 0 {
+		return nil, fmt.Errorf("no driver directories in %s", dir)
+	}
+
+	var created []string
+	for _, driverDir := range driverDirs {
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(driverDir, fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+			header := fmt.Sprintf("-- Migration %04d_%s (%s) pour %s.\n", next, name, direction, filepath.Base(driverDir))
+			if err := os.WriteFile(file, []byte(header), 0o644); err != nil {
+				return created, err
+			}
+			created = append(created, file)
+		}
+	}
+	return created, nil
+}
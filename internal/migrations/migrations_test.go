@@ -0,0 +1,278 @@
+package migrations
+
+import (
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"urlshortener/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// schemaModels sont les modèles dont les tables sont créées par les migrations.
+var schemaModels = []interface{}{
+	&models.Link{},
+	&models.Click{},
+	&models.ClickAggregate{},
+	&models.APIKey{},
+	&models.LinkCheck{},
+}
+
+// openMemoryDB ouvre une base SQLite en mémoire. Une seule connexion est ouverte : chaque
+// connexion à ":memory:" aurait sa propre base.
+func openMemoryDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// parseModel retourne le schéma GORM d'un modèle.
+func parseModel(t *testing.T, db *gorm.DB, model interface{}) *schema.Schema {
+	t.Helper()
+	s, err := schema.Parse(model, &sync.Map{}, db.NamingStrategy)
+	if err != nil {
+		t.Fatalf("parse %T: %v", model, err)
+	}
+	return s
+}
+
+// baselineLink et baselineClick sont les modèles de la version initiale du service, dont les
+// tables étaient créées par AutoMigrate avant l'introduction des migrations.
+type baselineLink struct {
+	ID        uint      `gorm:"primaryKey"`
+	ShortCode string    `gorm:"uniqueIndex;size:10"`
+	LongURL   string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (baselineLink) TableName() string { return "links" }
+
+type baselineClick struct {
+	ID        uint         `gorm:"primaryKey"`
+	LinkID    uint         `gorm:"index"`
+	Link      baselineLink `gorm:"foreignKey:LinkID"`
+	Timestamp time.Time
+	UserAgent string `gorm:"size:255"`
+	IPAddress string `gorm:"size:50"`
+}
+
+func (baselineClick) TableName() string { return "clicks" }
+
+func TestSQLiteMigrationsMatchModels(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, db *gorm.DB) // Prépare la base avant la première migration
+		check func(t *testing.T, db *gorm.DB) // Vérifie les données après migration
+	}{
+		{name: "empty database"},
+		{
+			name: "baseline AutoMigrate schema",
+			setup: func(t *testing.T, db *gorm.DB) {
+				if err := db.AutoMigrate(&baselineLink{}, &baselineClick{}); err != nil {
+					t.Fatalf("AutoMigrate: %v", err)
+				}
+				link := baselineLink{ShortCode: "abc", LongURL: "https://example.com"}
+				if err := db.Create(&link).Error; err != nil {
+					t.Fatal(err)
+				}
+				if err := db.Create(&baselineClick{LinkID: link.ID, Timestamp: time.Now(), UserAgent: "curl"}).Error; err != nil {
+					t.Fatal(err)
+				}
+			},
+			check: func(t *testing.T, db *gorm.DB) {
+				var link models.Link
+				if err := db.Where("short_code = ?", "abc").First(&link).Error; err != nil {
+					t.Fatalf("existing link lost: %v", err)
+				}
+				var click models.Click
+				if err := db.Where("link_id = ?", link.ID).First(&click).Error; err != nil {
+					t.Fatalf("existing click lost: %v", err)
+				}
+				if click.UserAgent != "curl" || click.IsBot {
+					t.Fatalf("existing click = %+v, want user agent curl and not a bot", click)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openMemoryDB(t)
+			if tt.setup != nil {
+				tt.setup(t, db)
+			}
+			migrator, err := New(db)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			applied, err := migrator.Up()
+			if err != nil {
+				t.Fatalf("Up: %v", err)
+			}
+			if len(applied) != len(migrator.migrations) {
+				t.Fatalf("Up applied %d migration(s), want %d", len(applied), len(migrator.migrations))
+			}
+
+			// Une seconde exécution, y compris avec un nouveau Migrator, n'applique rien.
+			migrator, err = New(db)
+			if err != nil {
+				t.Fatalf("New on migrated database: %v", err)
+			}
+			applied, err = migrator.Up()
+			if err != nil {
+				t.Fatalf("second Up: %v", err)
+			}
+			if len(applied) != 0 {
+				t.Fatalf("second Up applied %d migration(s), want 0", len(applied))
+			}
+			statuses, err := migrator.Status()
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			for _, status := range statuses {
+				if status.AppliedAt == nil || status.Missing {
+					t.Errorf("migration %d_%s: applied=%v missing=%v", status.Version, status.Name, status.AppliedAt != nil, status.Missing)
+				}
+			}
+
+			assertSchemaMatchesModels(t, db)
+			if tt.check != nil {
+				tt.check(t, db)
+			}
+		})
+	}
+}
+
+// assertSchemaMatchesModels vérifie que chaque table a exactement les colonnes de son modèle,
+// ainsi que tous ses index.
+func assertSchemaMatchesModels(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, model := range schemaModels {
+		s := parseModel(t, db, model)
+		columnTypes, err := db.Migrator().ColumnTypes(model)
+		if err != nil {
+			t.Fatalf("ColumnTypes(%s): %v", s.Table, err)
+		}
+		columns := make(map[string]bool, len(columnTypes))
+		for _, columnType := range columnTypes {
+			columns[columnType.Name()] = true
+		}
+
+		for _, field := range s.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !columns[field.DBName] {
+				t.Errorf("column %s.%s of the model is not created by the migrations", s.Table, field.DBName)
+			}
+			delete(columns, field.DBName)
+		}
+		for column := range columns {
+			t.Errorf("column %s.%s created by the migrations has no field in the model", s.Table, column)
+		}
+
+		for _, index := range s.ParseIndexes() {
+			if !db.Migrator().HasIndex(model, index.Name) {
+				t.Errorf("index %s of the model is not created by the migrations", index.Name)
+			}
+		}
+	}
+}
+
+var (
+	// statementTablePattern extrait la table d'une instruction CREATE TABLE ou ALTER TABLE.
+	statementTablePattern = regexp.MustCompile("(?i)^(?:CREATE TABLE(?: IF NOT EXISTS)?|ALTER TABLE)\\s+[`\"]?(\\w+)")
+	// varcharColumnPattern extrait les colonnes varchar(N) d'une instruction, y compris celles
+	// modifiées par ALTER COLUMN ... TYPE (PostgreSQL).
+	varcharColumnPattern = regexp.MustCompile("(?i)[`\"]?(\\w+)[`\"]?\\s+(?:TYPE\\s+)?varchar\\((\\d+)\\)")
+)
+
+// varcharColumns retourne la largeur des colonnes varchar déclarées par les migrations up d'un
+// pilote, indexée par "table.colonne". Une colonne redéclarée garde la dernière largeur.
+func varcharColumns(t *testing.T, driver string) map[string]int {
+	t.Helper()
+	dir := path.Join("sql", driver)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if match := fileNamePattern.FindStringSubmatch(entry.Name()); match != nil && match[3] == "up" {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	widths := make(map[string]int)
+	for _, name := range names {
+		content, err := fs.ReadFile(files, path.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, statement := range splitStatements(string(content)) {
+			table := statementTablePattern.FindStringSubmatch(statement)
+			if table == nil {
+				continue
+			}
+			for _, column := range varcharColumnPattern.FindAllStringSubmatch(statement, -1) {
+				width, _ := strconv.Atoi(column[2])
+				widths[table[1]+"."+column[1]] = width
+			}
+		}
+	}
+	return widths
+}
+
+// TestMigrationColumnWidthsMatchModels vérifie que la largeur des colonnes varchar des migrations
+// PostgreSQL et MySQL est celle de l'attribut size des modèles : une valeur acceptée par
+// l'application ne doit pas être rejetée ou tronquée par la base.
+func TestMigrationColumnWidthsMatchModels(t *testing.T) {
+	db := openMemoryDB(t)
+	for _, driver := range []string{"postgres", "mysql"} {
+		t.Run(driver, func(t *testing.T) {
+			widths := varcharColumns(t, driver)
+			for _, model := range schemaModels {
+				s := parseModel(t, db, model)
+				for _, field := range s.Fields {
+					if field.DBName == "" || field.FieldType.Kind() != reflect.String {
+						continue
+					}
+					key := s.Table + "." + field.DBName
+					width, declared := widths[key]
+					delete(widths, key)
+					switch {
+					case field.Size == 0 && declared:
+						t.Errorf("%s is varchar(%d) but the model sets no size", key, width)
+					case field.Size > 0 && !declared:
+						t.Errorf("%s is not a varchar but the model sets size:%d", key, field.Size)
+					case field.Size > 0 && width != field.Size:
+						t.Errorf("%s is varchar(%d) but the model sets size:%d", key, width, field.Size)
+					}
+				}
+			}
+			for key, width := range widths {
+				t.Errorf("%s is varchar(%d) but has no field in the models", key, width)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+-- Schéma initial : liens et clics, tels que créés par l'ancien AutoMigrate de GORM.
+-- IF NOT EXISTS permet d'adopter ces bases ; les colonnes ajoutées depuis le sont par 0002.
+-- Les index sont déclarés dans CREATE TABLE, MySQL ne supportant pas CREATE INDEX IF NOT EXISTS.
+CREATE TABLE IF NOT EXISTS `links` (
+    `id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    `short_code` varchar(10),
+    `long_url` longtext NOT NULL,
+    `created_at` datetime(3) NULL,
+    UNIQUE INDEX `idx_links_short_code` (`short_code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `clicks` (
+    `id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    `link_id` bigint unsigned,
+    `timestamp` datetime(3) NULL,
+    `user_agent` varchar(255),
+    `ip_address` varchar(50),
+    INDEX `idx_clicks_link_id` (`link_id`),
+    CONSTRAINT `fk_clicks_link` FOREIGN KEY (`link_id`) REFERENCES `links` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS `click_aggregates`;
+
+ALTER TABLE `clicks`
+    DROP INDEX `idx_clicks_visitor_hash`,
+    DROP INDEX `idx_clicks_is_bot`,
+    DROP INDEX `idx_clicks_referrer_host`,
+    DROP COLUMN `visitor_hash`,
+    DROP COLUMN `is_bot`,
+    DROP COLUMN `device`,
+    DROP COLUMN `os`,
+    DROP COLUMN `browser`,
+    DROP COLUMN `referrer_host`,
+    DROP COLUMN `referrer`;
+
+-- Échoue si des codes de plus de 10 caractères existent encore.
+ALTER TABLE `links`
+    DROP INDEX `idx_links_deleted_at`,
+    DROP COLUMN `deleted_at`,
+    DROP COLUMN `updated_at`,
+    DROP COLUMN `max_clicks`,
+    DROP COLUMN `expires_at`,
+    MODIFY COLUMN `short_code` varchar(10);
@@ -0,0 +1,30 @@
+-- Expiration et suppression réversible des liens, détail des clics et agrégats journaliers.
+-- Les alias personnalisés allongent les codes courts au-delà des 10 caractères générés.
+ALTER TABLE `links`
+    MODIFY COLUMN `short_code` varchar(32),
+    ADD COLUMN `expires_at` datetime(3) NULL,
+    ADD COLUMN `max_clicks` bigint NULL,
+    ADD COLUMN `updated_at` datetime(3) NULL,
+    ADD COLUMN `deleted_at` datetime(3) NULL,
+    ADD INDEX `idx_links_deleted_at` (`deleted_at`);
+
+ALTER TABLE `clicks`
+    ADD COLUMN `referrer` varchar(512),
+    ADD COLUMN `referrer_host` varchar(255),
+    ADD COLUMN `browser` varchar(50),
+    ADD COLUMN `os` varchar(50),
+    ADD COLUMN `device` varchar(20),
+    ADD COLUMN `is_bot` boolean DEFAULT false,
+    ADD COLUMN `visitor_hash` varchar(64),
+    ADD INDEX `idx_clicks_referrer_host` (`referrer_host`),
+    ADD INDEX `idx_clicks_is_bot` (`is_bot`),
+    ADD INDEX `idx_clicks_visitor_hash` (`visitor_hash`);
+
+CREATE TABLE `click_aggregates` (
+    `id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    `link_id` bigint unsigned,
+    `day` datetime(3) NULL,
+    `clicks` bigint NOT NULL DEFAULT 0,
+    `bot_clicks` bigint NOT NULL DEFAULT 0,
+    UNIQUE INDEX `idx_click_aggregates_link_day` (`link_id`, `day`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
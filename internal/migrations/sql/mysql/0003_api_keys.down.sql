@@ -0,0 +1,5 @@
+ALTER TABLE `links`
+    DROP FOREIGN KEY `fk_links_api_key`,
+    DROP INDEX `idx_links_api_key_id`,
+    DROP COLUMN `api_key_id`;
+DROP TABLE IF EXISTS `api_keys`;
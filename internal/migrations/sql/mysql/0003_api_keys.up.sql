@@ -0,0 +1,17 @@
+-- Clés API et propriété des liens.
+CREATE TABLE `api_keys` (
+    `id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    `name` varchar(100) NOT NULL,
+    `prefix` varchar(16) NOT NULL,
+    `hash` varchar(64) NOT NULL,
+    `scopes` varchar(255) NOT NULL,
+    `created_at` datetime(3) NULL,
+    `last_used_at` datetime(3) NULL,
+    `revoked_at` datetime(3) NULL,
+    UNIQUE INDEX `idx_api_keys_hash` (`hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE `links`
+    ADD COLUMN `api_key_id` bigint unsigned NULL,
+    ADD INDEX `idx_links_api_key_id` (`api_key_id`),
+    ADD CONSTRAINT `fk_links_api_key` FOREIGN KEY (`api_key_id`) REFERENCES `api_keys` (`id`);
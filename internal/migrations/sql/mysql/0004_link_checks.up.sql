@@ -0,0 +1,12 @@
+-- Historique des vérifications du moniteur d'URLs.
+CREATE TABLE `link_checks` (
+    `id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    `link_id` bigint unsigned,
+    `checked_at` datetime(3) NULL,
+    `up` boolean,
+    `status_code` bigint,
+    `latency_ms` bigint,
+    `error_class` varchar(32),
+    INDEX `idx_link_checks_link_checked_at` (`link_id`, `checked_at`),
+    INDEX `idx_link_checks_checked_at` (`checked_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
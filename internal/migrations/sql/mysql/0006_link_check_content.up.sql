@@ -0,0 +1,4 @@
+-- État détaillé des vérifications : URL finale après redirections et empreinte du début du contenu.
+ALTER TABLE `link_checks` ADD COLUMN `state` varchar(16);
+ALTER TABLE `link_checks` ADD COLUMN `final_url` varchar(2048);
+ALTER TABLE `link_checks` ADD COLUMN `content_hash` varchar(64);
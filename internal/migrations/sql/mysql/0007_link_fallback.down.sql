@@ -0,0 +1,3 @@
+ALTER TABLE `click_aggregates` DROP COLUMN `fallback_clicks`;
+ALTER TABLE `clicks` DROP COLUMN `fallback`;
+ALTER TABLE `links` DROP COLUMN `fallback_url`;
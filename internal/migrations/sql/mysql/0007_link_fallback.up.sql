@@ -0,0 +1,4 @@
+-- URL de repli des liens et décompte des redirections qui l'ont utilisée.
+ALTER TABLE `links` ADD COLUMN `fallback_url` longtext;
+ALTER TABLE `clicks` ADD COLUMN `fallback` boolean DEFAULT false;
+ALTER TABLE `click_aggregates` ADD COLUMN `fallback_clicks` bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE `clicks`
+    DROP INDEX `idx_clicks_request_id`,
+    DROP COLUMN `request_id`;
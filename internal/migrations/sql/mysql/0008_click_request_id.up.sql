@@ -0,0 +1,4 @@
+-- Identifiant de la requête de redirection de chaque clic (en-tête X-Request-ID).
+ALTER TABLE `clicks`
+    ADD COLUMN `request_id` varchar(64),
+    ADD INDEX `idx_clicks_request_id` (`request_id`);
@@ -0,0 +1,6 @@
+-- Redirections décomptées du budget de clics, incrémentées atomiquement à chaque redirection.
+-- Les liens existants dotés d'un budget reprennent le nombre de clics de visiteurs enregistrés.
+ALTER TABLE `links` ADD COLUMN `clicks_used` bigint NOT NULL DEFAULT 0;
+UPDATE `links` SET `clicks_used` = (
+    SELECT COUNT(*) FROM `clicks` WHERE `clicks`.`link_id` = `links`.`id` AND `clicks`.`is_bot` = false
+) WHERE `max_clicks` IS NOT NULL;
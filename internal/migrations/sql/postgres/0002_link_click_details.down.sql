@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS click_aggregates;
+
+ALTER TABLE clicks
+    DROP COLUMN IF EXISTS visitor_hash,
+    DROP COLUMN IF EXISTS is_bot,
+    DROP COLUMN IF EXISTS device,
+    DROP COLUMN IF EXISTS os,
+    DROP COLUMN IF EXISTS browser,
+    DROP COLUMN IF EXISTS referrer_host,
+    DROP COLUMN IF EXISTS referrer;
+
+ALTER TABLE links
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS max_clicks,
+    DROP COLUMN IF EXISTS expires_at;
+-- Échoue si des codes de plus de 10 caractères existent encore.
+ALTER TABLE links ALTER COLUMN short_code TYPE varchar(10);
@@ -0,0 +1,30 @@
+-- Expiration et suppression réversible des liens, détail des clics et agrégats journaliers.
+-- Les alias personnalisés allongent les codes courts au-delà des 10 caractères générés.
+ALTER TABLE links ALTER COLUMN short_code TYPE varchar(32);
+ALTER TABLE links
+    ADD COLUMN expires_at timestamptz,
+    ADD COLUMN max_clicks bigint,
+    ADD COLUMN updated_at timestamptz,
+    ADD COLUMN deleted_at timestamptz;
+CREATE INDEX idx_links_deleted_at ON links (deleted_at);
+
+ALTER TABLE clicks
+    ADD COLUMN referrer varchar(512),
+    ADD COLUMN referrer_host varchar(255),
+    ADD COLUMN browser varchar(50),
+    ADD COLUMN os varchar(50),
+    ADD COLUMN device varchar(20),
+    ADD COLUMN is_bot boolean DEFAULT false,
+    ADD COLUMN visitor_hash varchar(64);
+CREATE INDEX idx_clicks_referrer_host ON clicks (referrer_host);
+CREATE INDEX idx_clicks_is_bot ON clicks (is_bot);
+CREATE INDEX idx_clicks_visitor_hash ON clicks (visitor_hash);
+
+CREATE TABLE click_aggregates (
+    id bigserial PRIMARY KEY,
+    link_id bigint,
+    day timestamptz,
+    clicks bigint NOT NULL DEFAULT 0,
+    bot_clicks bigint NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX idx_click_aggregates_link_day ON click_aggregates (link_id, day);
@@ -0,0 +1,15 @@
+-- Clés API et propriété des liens.
+CREATE TABLE api_keys (
+    id bigserial PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    prefix varchar(16) NOT NULL,
+    hash varchar(64) NOT NULL,
+    scopes varchar(255) NOT NULL,
+    created_at timestamptz,
+    last_used_at timestamptz,
+    revoked_at timestamptz
+);
+CREATE UNIQUE INDEX idx_api_keys_hash ON api_keys (hash);
+
+ALTER TABLE links ADD COLUMN api_key_id bigint REFERENCES api_keys (id);
+CREATE INDEX idx_links_api_key_id ON links (api_key_id);
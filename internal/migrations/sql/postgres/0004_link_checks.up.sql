@@ -0,0 +1,12 @@
+-- Historique des vérifications du moniteur d'URLs.
+CREATE TABLE link_checks (
+    id bigserial PRIMARY KEY,
+    link_id bigint,
+    checked_at timestamptz,
+    up boolean,
+    status_code bigint,
+    latency_ms bigint,
+    error_class varchar(32)
+);
+CREATE INDEX idx_link_checks_link_checked_at ON link_checks (link_id, checked_at);
+CREATE INDEX idx_link_checks_checked_at ON link_checks (checked_at);
@@ -0,0 +1,2 @@
+-- Étiquettes des liens, utilisées pour router les notifications.
+ALTER TABLE links ADD COLUMN tags varchar(255);
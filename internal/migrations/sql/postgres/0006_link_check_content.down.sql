@@ -0,0 +1,3 @@
+ALTER TABLE link_checks DROP COLUMN IF EXISTS content_hash;
+ALTER TABLE link_checks DROP COLUMN IF EXISTS final_url;
+ALTER TABLE link_checks DROP COLUMN IF EXISTS state;
@@ -0,0 +1,3 @@
+ALTER TABLE click_aggregates DROP COLUMN IF EXISTS fallback_clicks;
+ALTER TABLE clicks DROP COLUMN IF EXISTS fallback;
+ALTER TABLE links DROP COLUMN IF EXISTS fallback_url;
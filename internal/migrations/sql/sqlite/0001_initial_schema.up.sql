@@ -0,0 +1,19 @@
+-- Schéma initial : liens et clics, tels que créés par l'ancien AutoMigrate de GORM.
+-- IF NOT EXISTS permet d'adopter ces bases ; les colonnes ajoutées depuis le sont par 0002.
+CREATE TABLE IF NOT EXISTS `links` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `short_code` text,
+    `long_url` text NOT NULL,
+    `created_at` datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_links_short_code` ON `links` (`short_code`);
+
+CREATE TABLE IF NOT EXISTS `clicks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `link_id` integer,
+    `timestamp` datetime,
+    `user_agent` text,
+    `ip_address` text,
+    CONSTRAINT `fk_clicks_link` FOREIGN KEY (`link_id`) REFERENCES `links` (`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_clicks_link_id` ON `clicks` (`link_id`);
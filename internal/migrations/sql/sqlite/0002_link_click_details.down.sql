@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS `click_aggregates`;
+
+DROP INDEX IF EXISTS `idx_clicks_visitor_hash`;
+DROP INDEX IF EXISTS `idx_clicks_is_bot`;
+DROP INDEX IF EXISTS `idx_clicks_referrer_host`;
+ALTER TABLE `clicks` DROP COLUMN `visitor_hash`;
+ALTER TABLE `clicks` DROP COLUMN `is_bot`;
+ALTER TABLE `clicks` DROP COLUMN `device`;
+ALTER TABLE `clicks` DROP COLUMN `os`;
+ALTER TABLE `clicks` DROP COLUMN `browser`;
+ALTER TABLE `clicks` DROP COLUMN `referrer_host`;
+ALTER TABLE `clicks` DROP COLUMN `referrer`;
+
+DROP INDEX IF EXISTS `idx_links_deleted_at`;
+ALTER TABLE `links` DROP COLUMN `deleted_at`;
+ALTER TABLE `links` DROP COLUMN `updated_at`;
+ALTER TABLE `links` DROP COLUMN `max_clicks`;
+ALTER TABLE `links` DROP COLUMN `expires_at`;
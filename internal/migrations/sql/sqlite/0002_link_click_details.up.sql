@@ -0,0 +1,26 @@
+-- Expiration et suppression réversible des liens, détail des clics et agrégats journaliers.
+ALTER TABLE `links` ADD COLUMN `expires_at` datetime;
+ALTER TABLE `links` ADD COLUMN `max_clicks` integer;
+ALTER TABLE `links` ADD COLUMN `updated_at` datetime;
+ALTER TABLE `links` ADD COLUMN `deleted_at` datetime;
+CREATE INDEX `idx_links_deleted_at` ON `links` (`deleted_at`);
+
+ALTER TABLE `clicks` ADD COLUMN `referrer` text;
+ALTER TABLE `clicks` ADD COLUMN `referrer_host` text;
+ALTER TABLE `clicks` ADD COLUMN `browser` text;
+ALTER TABLE `clicks` ADD COLUMN `os` text;
+ALTER TABLE `clicks` ADD COLUMN `device` text;
+ALTER TABLE `clicks` ADD COLUMN `is_bot` numeric DEFAULT false;
+ALTER TABLE `clicks` ADD COLUMN `visitor_hash` text;
+CREATE INDEX `idx_clicks_referrer_host` ON `clicks` (`referrer_host`);
+CREATE INDEX `idx_clicks_is_bot` ON `clicks` (`is_bot`);
+CREATE INDEX `idx_clicks_visitor_hash` ON `clicks` (`visitor_hash`);
+
+CREATE TABLE `click_aggregates` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `link_id` integer,
+    `day` datetime,
+    `clicks` integer NOT NULL DEFAULT 0,
+    `bot_clicks` integer NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX `idx_click_aggregates_link_day` ON `click_aggregates` (`link_id`, `day`);
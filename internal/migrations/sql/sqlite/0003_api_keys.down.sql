@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_links_api_key_id`;
+ALTER TABLE `links` DROP COLUMN `api_key_id`;
+DROP TABLE IF EXISTS `api_keys`;
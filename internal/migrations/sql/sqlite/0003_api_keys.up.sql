@@ -0,0 +1,16 @@
+-- Clés API et propriété des liens.
+CREATE TABLE `api_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `prefix` text NOT NULL,
+    `hash` text NOT NULL,
+    `scopes` text NOT NULL,
+    `created_at` datetime,
+    `last_used_at` datetime,
+    `revoked_at` datetime
+);
+CREATE UNIQUE INDEX `idx_api_keys_hash` ON `api_keys` (`hash`);
+
+-- Pas de contrainte de clé étrangère : SQLite ne permettrait plus de supprimer la colonne (down).
+ALTER TABLE `links` ADD COLUMN `api_key_id` integer;
+CREATE INDEX `idx_links_api_key_id` ON `links` (`api_key_id`);
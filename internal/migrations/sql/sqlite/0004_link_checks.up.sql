@@ -0,0 +1,12 @@
+-- Historique des vérifications du moniteur d'URLs.
+CREATE TABLE `link_checks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `link_id` integer,
+    `checked_at` datetime,
+    `up` numeric,
+    `status_code` integer,
+    `latency_ms` integer,
+    `error_class` text
+);
+CREATE INDEX `idx_link_checks_link_checked_at` ON `link_checks` (`link_id`, `checked_at`);
+CREATE INDEX `idx_link_checks_checked_at` ON `link_checks` (`checked_at`);
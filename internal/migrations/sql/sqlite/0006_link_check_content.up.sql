@@ -0,0 +1,4 @@
+-- État détaillé des vérifications : URL finale après redirections et empreinte du début du contenu.
+ALTER TABLE `link_checks` ADD COLUMN `state` text;
+ALTER TABLE `link_checks` ADD COLUMN `final_url` text;
+ALTER TABLE `link_checks` ADD COLUMN `content_hash` text;
@@ -0,0 +1,4 @@
+-- URL de repli des liens et décompte des redirections qui l'ont utilisée.
+ALTER TABLE `links` ADD COLUMN `fallback_url` text;
+ALTER TABLE `clicks` ADD COLUMN `fallback` numeric DEFAULT false;
+ALTER TABLE `click_aggregates` ADD COLUMN `fallback_clicks` integer NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_clicks_request_id`;
+ALTER TABLE `clicks` DROP COLUMN `request_id`;
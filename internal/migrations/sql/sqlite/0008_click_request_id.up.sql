@@ -0,0 +1,3 @@
+-- Identifiant de la requête de redirection de chaque clic (en-tête X-Request-ID).
+ALTER TABLE `clicks` ADD COLUMN `request_id` text;
+CREATE INDEX `idx_clicks_request_id` ON `clicks` (`request_id`);
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// APIKey est une clé d'accès à l'API /api/v1. Seule l'empreinte SHA-256 de la clé est stockée :
+// la clé en clair n'est affichée qu'une fois, à sa création.
+type APIKey struct {
+	ID         uint   `gorm:"primaryKey"`
+	Name       string `gorm:"size:100;not null"` // Libellé choisi à la création
+	Prefix     string `gorm:"size:16;not null"`  // Début de la clé, affiché pour l'identifier
+	Hash       string `gorm:"size:64;not null;uniqueIndex"`
+	Scopes     string `gorm:"size:255;not null"` // Permissions séparées par des espaces (ex: "links:write stats:read")
+	CreatedAt  time.Time
+	LastUsedAt *time.Time // Dernière utilisation (mise à jour au plus une fois par minute)
+	RevokedAt  *time.Time // Date de révocation (NULL = clé active)
+}
+
+// TableName fixe le nom de la table des clés API.
+func (APIKey) TableName() string {
+	return "api_keys"
+}
@@ -11,6 +11,16 @@ type Click struct {
 	Timestamp time.Time // Horodatage précis du clic
 	UserAgent string    `gorm:"size:255"` // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
 	IPAddress string    `gorm:"size:50"`  // Adresse IP de l'utilisateur
+
+	Referrer     string `gorm:"size:512"`            // En-tête Referer de la requête de redirection (vide si accès direct)
+	ReferrerHost string `gorm:"size:255;index"`      // Domaine extrait du Referer, utilisé pour les regroupements
+	Browser      string `gorm:"size:50"`             // Navigateur déduit du User-Agent
+	OS           string `gorm:"size:50"`             // Système d'exploitation déduit du User-Agent
+	Device       string `gorm:"size:20"`             // Classe d'appareil déduite du User-Agent (desktop, mobile, tablet, other)
+	IsBot        bool   `gorm:"default:false;index"` // Clic attribué à un robot ou à un générateur d'aperçu
+	VisitorHash  string `gorm:"size:64;index"`       // Empreinte anonyme du visiteur, renouvelée chaque jour
+	Fallback     bool   `gorm:"default:false"`       // Redirection servie vers l'URL de repli du lien
+	RequestID    string `gorm:"size:64;index"`       // Identifiant de la requête de redirection (en-tête X-Request-ID)
 }
 
 // TODO créer la struct pour ClickEvent
@@ -22,4 +32,11 @@ type ClickEvent struct {
 	Timestamp time.Time
 	UserAgent string
 	IPAddress string
+	Referrer  string
+	Method    string // Méthode HTTP de la requête (GET, HEAD)
+	Accept    string // En-tête Accept, utilisé pour détecter les robots
+	Fallback  bool   // Redirection servie vers l'URL de repli du lien
+	RequestID string // Identifiant de la requête de redirection, repris dans les journaux
+
+	QueueSegment uint64 `json:"-"` // Segment de la file durable d'où provient l'événement (0 si aucun)
 }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ClickAggregate conserve le nombre de clics d'un lien pour une journée (UTC) une fois
+// les clics individuels supprimés par la politique de rétention en mode "aggregate".
+// Les totaux de clics d'un lien additionnent ces agrégats aux clics encore détaillés.
+type ClickAggregate struct {
+	ID             uint      `gorm:"primaryKey"`
+	LinkID         uint      `gorm:"uniqueIndex:idx_click_aggregates_link_day"` // Lien concerné
+	Day            time.Time `gorm:"uniqueIndex:idx_click_aggregates_link_day"` // Jour (UTC, à minuit) des clics agrégés
+	Clicks         int       `gorm:"not null;default:0"`                        // Clics de visiteurs humains
+	BotClicks      int       `gorm:"not null;default:0"`                        // Clics attribués à des robots
+	FallbackClicks int       `gorm:"not null;default:0"`                        // Redirections servies vers l'URL de repli, robots compris
+}
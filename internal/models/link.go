@@ -1,19 +1,58 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // TODO : Créer la struct Link
 // Link représente un lien raccourci dans la base de données.
 // Les tags `gorm:"..."` définissent comment GORM doit mapper cette structure à une table SQL.
 // ID qui est une primaryKey
-// Shortcode : doit être unique, indexé pour des recherches rapide (voir doc), taille max 10 caractères
+// Shortcode : doit être unique, indexé pour des recherches rapide (voir doc), taille max 32 caractères
+// (les codes générés font 6 caractères, les alias personnalisés jusqu'à 32)
 // LongURL : doit pas être null
 // CreateAt : Horodatage de la créatino du lien
+// ExpiresAt : Date d'expiration optionnelle (NULL = pas d'expiration)
+// MaxClicks : Nombre maximum de clics optionnel (NULL = illimité)
+// ClicksUsed : Redirections de visiteurs déjà décomptées de MaxClicks, incrémentées en base à chaque redirection
+// UpdatedAt : Horodatage de la dernière modification
+// DeletedAt : Horodatage de suppression logique (soft delete), géré automatiquement par GORM
+// APIKeyID : Clé API ayant créé le lien (NULL pour les liens créés via la CLI)
+// Tags : Étiquettes libres séparées par des virgules, utilisées pour router les notifications
+// FallbackURL : URL de repli optionnelle, servie quand le moniteur constate que LongURL est inaccessible
 
 type Link struct {
-	ID        uint      `gorm:"primaryKey"`
-	ShortCode string    `gorm:"uniqueIndex;size:10"`
-	LongURL   string    `gorm:"not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	clicks    []Click
+	ID          uint      `gorm:"primaryKey"`
+	ShortCode   string    `gorm:"uniqueIndex;size:32"`
+	LongURL     string    `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	ExpiresAt   *time.Time
+	MaxClicks   *int
+	ClicksUsed  int            `gorm:"not null;default:0"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	APIKeyID    *uint          `gorm:"index"`
+	Tags        string         `gorm:"size:255"`
+	FallbackURL string
+	clicks      []Click
+}
+
+// TagList retourne les étiquettes du lien.
+func (l *Link) TagList() []string {
+	if l.Tags == "" {
+		return []string{}
+	}
+	return strings.Split(l.Tags, ",")
+}
+
+// HasExpired indique si le lien a dépassé sa date d'expiration à l'instant 'now'
+// ou si ses redirections décomptées (ClicksUsed) ont atteint son budget.
+func (l *Link) HasExpired(now time.Time) bool {
+	if l.ExpiresAt != nil && !now.Before(*l.ExpiresAt) {
+		return true
+	}
+	return l.MaxClicks != nil && l.ClicksUsed >= *l.MaxClicks
 }
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// États d'une vérification.
+const (
+	CheckStateUp             = "up"
+	CheckStateDown           = "down"
+	CheckStateContentChanged = "content_changed" // Accessible, mais le début du contenu diffère de la vérification précédente
+)
+
+// LinkCheck est le résultat d'une vérification de l'URL longue d'un lien par le moniteur.
+type LinkCheck struct {
+	ID          uint      `gorm:"primaryKey"`
+	LinkID      uint      `gorm:"index:idx_link_checks_link_checked_at,priority:1"`
+	CheckedAt   time.Time `gorm:"index:idx_link_checks_link_checked_at,priority:2;index"`
+	Up          bool      // URL accessible (code HTTP 2xx ou 3xx)
+	State       string    `gorm:"size:16"` // up, down ou content_changed (vide pour les vérifications antérieures)
+	StatusCode  int       // Code HTTP de la réponse, 0 si aucune réponse n'a été reçue
+	LatencyMs   int       // Durée de la vérification en millisecondes
+	ErrorClass  string    `gorm:"size:32"`   // Catégorie d'échec (timeout, dns, http_status...), vide si accessible
+	FinalURL    string    `gorm:"size:2048"` // URL atteinte après les redirections
+	ContentHash string    `gorm:"size:64"`   // SHA-256 hexadécimal du premier Ko du contenu, vide si le contenu n'a pas été lu
+}
+
+// CheckState retourne l'état de la vérification, déduit de Up pour les vérifications antérieures à State.
+func (c *LinkCheck) CheckState() string {
+	switch {
+	case c.State != "":
+		return c.State
+	case c.Up:
+		return CheckStateUp
+	default:
+		return CheckStateDown
+	}
+}
@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"urlshortener/internal/urlpolicy"
+)
+
+// ClientOptions configure le client HTTP utilisé pour vérifier les URLs surveillées.
+type ClientOptions struct {
+	Timeout          time.Duration // Durée maximale d'une vérification, redirections comprises
+	MaxRedirects     int           // Nombre maximum de redirections suivies
+	MaxResponseBytes int64         // Taille maximale lue d'une réponse (en-têtes et corps)
+	AllowPrivate     bool          // Autorise les adresses non publiques (tests en réseau local uniquement)
+}
+
+// errForbiddenAddress est retournée quand une connexion vers une adresse non publique est refusée.
+var errForbiddenAddress = errors.New("connection to non-public address refused")
+
+// errTooManyRedirects est retournée quand une vérification dépasse le nombre de redirections autorisées.
+var errTooManyRedirects = errors.New("too many redirects")
+
+// errInvalidURL est retournée quand la requête de vérification ne peut pas être créée à partir de l'URL.
+var errInvalidURL = errors.New("invalid URL")
+
+// Catégories d'échec d'une vérification, enregistrées dans models.LinkCheck.ErrorClass.
+const (
+	ErrorClassInvalidURL        = "invalid_url"
+	ErrorClassDNS               = "dns"
+	ErrorClassTimeout           = "timeout"
+	ErrorClassConnectionRefused = "connection_refused"
+	ErrorClassForbiddenAddress  = "forbidden_address"
+	ErrorClassTLS               = "tls"
+	ErrorClassTooManyRedirects  = "too_many_redirects"
+	ErrorClassConnection        = "connection"  // Autre erreur réseau
+	ErrorClassHTTPStatus        = "http_status" // Réponse reçue avec un code 4xx ou 5xx
+)
+
+// classifyError retourne la catégorie d'une erreur retournée par le client HTTP.
+func classifyError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	switch {
+	case errors.Is(err, errInvalidURL):
+		return ErrorClassInvalidURL
+	case errors.Is(err, errForbiddenAddress):
+		return ErrorClassForbiddenAddress
+	case errors.Is(err, errTooManyRedirects):
+		return ErrorClassTooManyRedirects
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassConnectionRefused
+	case errors.As(err, &certErr), errors.As(err, &recordErr):
+		return ErrorClassTLS
+	}
+	return ErrorClassConnection
+}
+
+// NewHTTPClient crée le client HTTP partagé par toutes les vérifications du moniteur.
+// Les URLs surveillées étant fournies par les utilisateurs, chaque connexion est contrôlée
+// au moment de l'ouverture du socket, après la résolution DNS : une adresse privée, de bouclage,
+// lien-local ou de métadonnées cloud est refusée, y compris lorsqu'elle est atteinte par une
+// redirection ou un changement de réponse DNS entre deux requêtes. Les proxys d'environnement
+// sont ignorés pour que ce contrôle porte sur la destination réelle.
+func NewHTTPClient(opts ClientOptions) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   opts.Timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if !opts.AllowPrivate {
+		dialer.Control = refuseNonPublic
+	}
+
+	transport := &http.Transport{
+		Proxy:                  nil,
+		DialContext:            dialer.DialContext,
+		TLSHandshakeTimeout:    opts.Timeout,
+		ResponseHeaderTimeout:  opts.Timeout,
+		MaxIdleConns:           100,
+		MaxIdleConnsPerHost:    2,
+		IdleConnTimeout:        90 * time.Second,
+		MaxResponseHeaderBytes: opts.MaxResponseBytes,
+	}
+
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return fmt.Errorf("%w: stopped after %d redirects", errTooManyRedirects, opts.MaxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// refuseNonPublic est appelée par le dialer juste avant chaque connexion, avec l'adresse IP résolue.
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errForbiddenAddress, address)
+	}
+	if !urlpolicy.IsPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errForbiddenAddress, addrPort.Addr().Unmap())
+	}
+	return nil
+}
+
+// drainBody lit au plus 'limit' octets restants du corps d'une réponse puis le ferme,
+// afin de réutiliser la connexion sans jamais télécharger une réponse démesurée.
+func drainBody(resp *http.Response, limit int64) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, limit))
+	resp.Body.Close()
+}
@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"context"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"urlshortener/internal/models"
+)
+
+// CheckOptions configure les vérifications d'une passe du moniteur et leur répartition.
+type CheckOptions struct {
+	ContentFingerprint bool          // Lire le premier Ko du contenu (GET) pour détecter ses changements, au lieu d'une requête HEAD
+	Workers            int           // Nombre de vérifications simultanées
+	PerHostConcurrency int           // Nombre maximum de vérifications simultanées vers un même hôte
+	RatePerSecond      float64       // Nombre maximum de vérifications lancées par seconde (0 = illimité)
+	Jitter             time.Duration // Les vérifications démarrent à un instant aléatoire dans [0, Jitter)
+	HistoryRetention   time.Duration // Durée de conservation de l'historique des vérifications (0 = illimitée)
+}
+
+// scheduledCheck est la vérification d'un lien, à lancer 'delay' après le début de la passe.
+type scheduledCheck struct {
+	link  models.Link
+	host  string
+	delay time.Duration
+}
+
+// hostLimiter limite le nombre de vérifications simultanées vers chaque hôte.
+type hostLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &hostLimiter{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+// acquire attend une place libre pour 'host'. Il retourne false si ctx est annulé entre-temps.
+func (h *hostLimiter) acquire(ctx context.Context, host string) bool {
+	h.mu.Lock()
+	slot, ok := h.slots[host]
+	if !ok {
+		slot = make(chan struct{}, h.limit)
+		h.slots[host] = slot
+	}
+	h.mu.Unlock()
+
+	select {
+	case slot <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (h *hostLimiter) release(host string) {
+	h.mu.Lock()
+	slot := h.slots[host]
+	h.mu.Unlock()
+	<-slot
+}
+
+// scheduleChecks attribue à chaque lien un délai aléatoire dans [0, jitter) et les trie par délai.
+func scheduleChecks(links []models.Link, jitter time.Duration) []scheduledCheck {
+	checks := make([]scheduledCheck, len(links))
+	for i, link := range links {
+		checks[i] = scheduledCheck{link: link, host: checkHost(link.LongURL)}
+		if jitter > 0 {
+			checks[i].delay = time.Duration(rand.Int63n(int64(jitter)))
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].delay < checks[j].delay })
+	return checks
+}
+
+// checkHost retourne l'hôte d'une URL, utilisé comme clé de la limite par hôte.
+func checkHost(longURL string) string {
+	if u, err := url.Parse(longURL); err == nil {
+		return strings.ToLower(u.Hostname())
+	}
+	return longURL
+}
+
+// runChecks exécute 'check' pour chaque vérification planifiée avec opts.Workers goroutines,
+// en respectant les délais, la limite de débit globale et la limite par hôte. Il retourne
+// une fois toutes les vérifications lancées terminées, ou dès l'annulation de ctx.
+func runChecks(ctx context.Context, checks []scheduledCheck, opts CheckOptions, check func(ctx context.Context, link models.Link)) {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	hosts := newHostLimiter(opts.PerHostConcurrency)
+	jobs := make(chan scheduledCheck)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if !hosts.acquire(ctx, job.host) {
+					continue
+				}
+				check(ctx, job.link)
+				hosts.release(job.host)
+			}
+		}()
+	}
+
+	var rate *time.Ticker
+	if opts.RatePerSecond > 0 {
+		// Un débit démesuré arrondirait l'intervalle à 0, que NewTicker refuse.
+		rate = time.NewTicker(max(time.Duration(float64(time.Second)/opts.RatePerSecond), time.Nanosecond))
+		defer rate.Stop()
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+dispatch:
+	for _, job := range checks {
+		if wait := time.Until(start.Add(job.delay)); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		if rate != nil {
+			select {
+			case <-rate.C:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
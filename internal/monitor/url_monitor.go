@@ -1,112 +1,361 @@
 package monitor
 
 import (
-	"log"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"sync" // Pour protéger l'accès concurrentiel à knownStates
 	"time"
 
-	_ "urlshortener/internal/models"   // Importe les modèles de liens
+	"urlshortener/internal/metrics"
+	"urlshortener/internal/models" // Importe les modèles de liens
+	"urlshortener/internal/notify"
 	"urlshortener/internal/repository" // Importe le repository de liens
 )
 
 // UrlMonitor gère la surveillance périodique des URLs longues.
 type UrlMonitor struct {
-	linkRepo    repository.LinkRepository // Pour récupérer les URLs à surveiller
-	interval    time.Duration             // Intervalle entre chaque vérification (ex: 5 minutes)
-	knownStates map[uint]bool             // État connu de chaque URL: map[LinkID]estAccessible (true/false)
-	mu          sync.Mutex                // Mutex pour protéger l'accès concurrentiel à knownStates
+	linkRepo    repository.LinkRepository  // Pour récupérer les URLs à surveiller
+	checkRepo   repository.CheckRepository // Pour enregistrer l'historique des vérifications
+	notifier    *notify.Dispatcher         // Destinataire des changements d'état (nil = journal uniquement)
+	interval    time.Duration              // Intervalle entre chaque vérification (ex: 5 minutes)
+	knownStates map[uint]bool              // État connu de chaque URL: map[LinkID]estAccessible (true/false), restauré depuis l'historique au démarrage
+	fingerprint map[uint]string            // Dernière empreinte du contenu de chaque URL, restaurée depuis l'historique au démarrage
+	failures    map[uint]int               // Échecs consécutifs de chaque URL, restaurés depuis l'historique au démarrage
+	checkedURLs map[uint]string            // URL longue vérifiée pour chaque lien (absente si l'état a été restauré)
+	mu          sync.Mutex                 // Mutex pour protéger l'accès concurrentiel aux maps d'état
+
+	client           *http.Client // Client partagé par toutes les vérifications (voir NewHTTPClient)
+	maxResponseBytes int64        // Taille maximale lue d'une réponse
+	checkOpts        CheckOptions // Répartition des vérifications d'une passe
+	logger           *slog.Logger // Journal du moniteur (component=monitor)
+
+	cancel context.CancelFunc // Interrompt la boucle et les requêtes en cours (voir Stop)
+	wg     sync.WaitGroup     // Attend la fin de la boucle de surveillance
 }
 
 // NewUrlMonitor crée et retourne une nouvelle instance de UrlMonitor.
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
+// Les URLs sont vérifiées avec un client HTTP créé selon clientOpts, et réparties selon checkOpts.
+// Les changements d'état sont transmis à notifier, qui peut être nil.
+func NewUrlMonitor(linkRepo repository.LinkRepository, checkRepo repository.CheckRepository, notifier *notify.Dispatcher, interval time.Duration, clientOpts ClientOptions, checkOpts CheckOptions) *UrlMonitor {
 	return &UrlMonitor{
-		linkRepo:    linkRepo,
-		interval:    interval,
-		knownStates: make(map[uint]bool),
-		mu:          sync.Mutex{},
+		linkRepo:         linkRepo,
+		checkRepo:        checkRepo,
+		notifier:         notifier,
+		interval:         interval,
+		knownStates:      make(map[uint]bool),
+		fingerprint:      make(map[uint]string),
+		failures:         make(map[uint]int),
+		checkedURLs:      make(map[uint]string),
+		mu:               sync.Mutex{},
+		client:           NewHTTPClient(clientOpts),
+		maxResponseBytes: clientOpts.MaxResponseBytes,
+		checkOpts:        checkOpts,
+		logger:           slog.With("component", "monitor"),
 	}
 }
 
-// Start lance la boucle de surveillance périodique des URLs.
-// Cette fonction est conçue pour être lancée dans une goroutine séparée.
+// Start lance la boucle de surveillance périodique des URLs dans une goroutine séparée.
+// Elle s'arrête avec Stop.
 func (m *UrlMonitor) Start() {
-	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v...", m.interval)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	m.wg.Add(1)
+	go m.run(ctx)
+}
+
+// Stop interrompt la vérification en cours et attend la fin de la boucle de surveillance.
+func (m *UrlMonitor) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	m.wg.Wait()
+	m.logger.Info("Moniteur d'URLs arrêté")
+}
+
+// run est la boucle de surveillance, exécutée jusqu'à l'annulation de ctx.
+func (m *UrlMonitor) run(ctx context.Context) {
+	defer m.wg.Done()
+
+	m.logger.Info("Démarrage du moniteur d'URLs", "interval", m.interval.String())
 	ticker := time.NewTicker(m.interval) // Crée un ticker qui envoie un signal à chaque intervalle
-	defer ticker.Stop()                  // S'assure que le ticker est arrêté quand Start se termine
+	defer ticker.Stop()                  // S'assure que le ticker est arrêté quand la boucle se termine
+
+	// Les passes sont exécutées l'une après l'autre dans cette goroutine : elles ne se chevauchent jamais.
+	// Une passe plus longue que l'intervalle laisse un tick en attente, qui est ignoré
+	// pour ne pas enchaîner immédiatement une nouvelle passe.
+	checkAndSkipOverdueTick := func() {
+		m.checkUrls(ctx)
+		select {
+		case <-ticker.C:
+			m.logger.Warn("La vérification a dépassé l'intervalle, le tick suivant est ignoré", "interval", m.interval.String())
+		default:
+		}
+	}
+
+	m.restoreState()
 
 	// Exécute une première vérification immédiatement au démarrage
-	m.checkUrls()
+	checkAndSkipOverdueTick()
 
 	// Boucle principale du moniteur, déclenchée par le ticker
-	for range ticker.C {
-		m.checkUrls()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkAndSkipOverdueTick()
+		}
+	}
+}
+
+// restoreState reprend les états connus avant le redémarrage depuis l'historique des vérifications,
+// pour notifier les changements survenus entre-temps et conserver les redirections de repli en cours.
+func (m *UrlMonitor) restoreState() {
+	states, err := m.checkRepo.GetLatestStates()
+	if err != nil {
+		m.logger.Error("Erreur lors de la lecture des derniers états connus", "error", err)
+	}
+	fingerprints, err := m.checkRepo.GetLatestFingerprints()
+	if err != nil {
+		m.logger.Error("Erreur lors de la lecture des dernières empreintes de contenu", "error", err)
+	}
+	failures, err := m.checkRepo.GetConsecutiveFailures()
+	if err != nil {
+		m.logger.Error("Erreur lors de la lecture des échecs consécutifs", "error", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if states != nil {
+		m.knownStates = states
 	}
+	if fingerprints != nil {
+		m.fingerprint = fingerprints
+	}
+	if failures != nil {
+		m.failures = failures
+	}
+}
+
+// ConsecutiveFailures retourne le nombre de vérifications consécutives en échec de l'URL longue
+// d'un lien, ou 0 si sa dernière vérification a réussi ou porte sur une autre URL.
+func (m *UrlMonitor) ConsecutiveFailures(link *models.Link) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if checked, ok := m.checkedURLs[link.ID]; ok && checked != link.LongURL {
+		return 0
+	}
+	return m.failures[link.ID]
 }
 
 // checkUrls effectue une vérification de l'état de toutes les URLs longues enregistrées.
-func (m *UrlMonitor) checkUrls() {
-	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
+// Les vérifications sont réparties selon checkOpts (voir runChecks) et abandonnées dès l'annulation de ctx.
+func (m *UrlMonitor) checkUrls(ctx context.Context) {
+	m.logger.Info("Lancement de la vérification de l'état des URLs")
+	started := time.Now()
 
 	// Gérer l'erreur si la récupération échoue.
 	links, err := m.linkRepo.GetAllLinks()
 	if err != nil {
-		log.Printf("[MONITOR] ERREUR lors de la récupération des liens pour la surveillance : %v", err)
+		m.logger.Error("Erreur lors de la récupération des liens pour la surveillance", "error", err)
+		return
+	}
+
+	runChecks(ctx, scheduleChecks(links, m.checkOpts.Jitter), m.checkOpts, m.checkLink)
+	if ctx.Err() != nil {
+		m.logger.Info("Vérification interrompue")
+		return
+	}
+	metrics.ObserveSince(metrics.MonitorPassDuration, started)
+	m.logger.Info("Vérification de l'état des URLs terminée", "links", len(links), "duration", time.Since(started).Round(time.Millisecond).String())
+
+	if m.checkOpts.HistoryRetention > 0 {
+		if _, err := m.checkRepo.DeleteChecksBefore(time.Now().Add(-m.checkOpts.HistoryRetention)); err != nil {
+			m.logger.Error("Erreur lors de la purge de l'historique des vérifications", "error", err)
+		}
 	}
+}
 
-	for _, link := range links {
-		// Vérifier l'accessibilité de l'URL
-		currentState := m.isUrlAccessible(link.LongURL)
+// checkLink vérifie l'accessibilité d'un lien, enregistre le résultat et notifie un éventuel changement d'état
+// ou de contenu.
+func (m *UrlMonitor) checkLink(ctx context.Context, link models.Link) {
+	// Vérifier l'accessibilité de l'URL
+	started := time.Now()
+	check := m.checkURL(ctx, link.LongURL)
+	if ctx.Err() != nil {
+		// La requête a été annulée : l'état obtenu n'est pas significatif.
+		return
+	}
+	metrics.ObserveSince(metrics.MonitorCheckDuration, started)
+	check.LinkID = link.ID
+	currentState := check.Up
 
-		// Protéger l'accès à la map 'knownStates' car 'checkUrls' peut être exécuté concurremment
-		m.mu.Lock()
-		previousState, exists := m.knownStates[link.ID]
-		m.knownStates[link.ID] = currentState
-		m.mu.Unlock()
+	// Protéger l'accès aux maps car les vérifications sont exécutées concurremment
+	m.mu.Lock()
+	if checked, ok := m.checkedURLs[link.ID]; ok && checked != link.LongURL {
+		// La destination du lien a changé : son état, son empreinte et ses échecs ne la concernent plus,
+		// la vérification est traitée comme la première du lien.
+		m.forgetLocked(link.ID)
+	}
+	m.checkedURLs[link.ID] = link.LongURL
+	previousState, exists := m.knownStates[link.ID]
+	m.knownStates[link.ID] = currentState
+	previousHash := m.fingerprint[link.ID]
+	if check.ContentHash != "" {
+		m.fingerprint[link.ID] = check.ContentHash
+	}
+	if currentState {
+		delete(m.failures, link.ID)
+	} else {
+		m.failures[link.ID]++
+	}
+	m.mu.Unlock()
 
-		// Si c'est la première vérification pour ce lien, on initialise l'état sans notifier.
-		if !exists {
-			log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
-				link.ShortCode, link.LongURL, formatState(currentState))
-			continue
+	// Le nouveau contenu devient la référence : l'état content_changed ne concerne que la vérification
+	// où le changement est détecté.
+	check.State = models.CheckStateDown
+	if check.Up {
+		check.State = models.CheckStateUp
+		if previousHash != "" && check.ContentHash != "" && check.ContentHash != previousHash {
+			check.State = models.CheckStateContentChanged
 		}
+	}
+	metrics.MonitorChecks.WithLabelValues(check.State).Inc()
+	if err := m.checkRepo.CreateCheck(&check); err != nil {
+		m.logger.Error("Erreur lors de l'enregistrement de la vérification du lien", "short_code", link.ShortCode, "error", err)
+	}
+
+	// Si c'est la première vérification pour ce lien, on initialise l'état sans notifier.
+	if !exists {
+		m.logger.Info("État initial du lien",
+			"short_code", link.ShortCode, "long_url", link.LongURL, "state", formatState(currentState))
+		return
+	}
+
+	// Notifier si l'état a changé
+	if previousState != currentState {
+		m.logger.Warn("[NOTIFICATION] Changement d'état du lien",
+			"short_code", link.ShortCode,
+			"long_url", link.LongURL,
+			"from", formatState(previousState),
+			"to", formatState(currentState))
 
-		// Notifier si l'état a changé
-		if previousState != currentState {
-			log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
-				link.ShortCode,
-				link.LongURL,
-				formatState(previousState),
-				formatState(currentState))
+		eventType := notify.EventLinkDown
+		if currentState {
+			eventType = notify.EventLinkUp
 		}
+		m.notifier.Notify(newEvent(eventType, link, check))
 	}
-	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
+
+	// Notifier un changement de contenu (page de parking, soft 404, destination détournée...)
+	if check.State == models.CheckStateContentChanged {
+		m.logger.Warn("[NOTIFICATION] Changement du contenu du lien",
+			"short_code", link.ShortCode, "long_url", link.LongURL, "final_url", check.FinalURL)
+		m.notifier.Notify(newEvent(notify.EventLinkContentChanged, link, check))
+	}
+}
+
+// forgetLocked oublie l'état connu d'un lien. m.mu doit être verrouillé.
+func (m *UrlMonitor) forgetLocked(linkID uint) {
+	delete(m.knownStates, linkID)
+	delete(m.fingerprint, linkID)
+	delete(m.failures, linkID)
+	delete(m.checkedURLs, linkID)
 }
 
-// isUrlAccessible effectue une requête HTTP HEAD pour vérifier l'accessibilité d'une URL.
-func (m *UrlMonitor) isUrlAccessible(url string) bool {
-	// Création d'un client HTTP avec timeout
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+// newEvent construit l'événement de type 'eventType' décrivant la vérification d'un lien.
+func newEvent(eventType string, link models.Link, check models.LinkCheck) notify.Event {
+	return notify.Event{
+		Type:       eventType,
+		LinkID:     link.ID,
+		ShortCode:  link.ShortCode,
+		LongURL:    link.LongURL,
+		OwnerKeyID: link.APIKeyID,
+		Tags:       link.TagList(),
+		Up:         check.Up,
+		StatusCode: check.StatusCode,
+		ErrorClass: check.ErrorClass,
+		FinalURL:   check.FinalURL,
+		At:         check.CheckedAt,
 	}
+}
 
-	// Création de la requête HEAD
-	req, err := http.NewRequest("HEAD", url, nil)
-	if err != nil {
-		log.Printf("[MONITOR] Erreur de création de la requête pour '%s': %v", url, err)
-		return false
+// fingerprintBytes est la taille du début de contenu dont l'empreinte est conservée.
+const fingerprintBytes = 1024
+
+// checkURL vérifie l'accessibilité d'une URL et retourne le résultat de la vérification, sans LinkID ni State.
+// La vérification utilise une requête HEAD, ou une requête GET limitée au premier Ko (en-tête Range)
+// lorsque l'empreinte du contenu est activée ou que le serveur refuse HEAD (405 ou 501).
+func (m *UrlMonitor) checkURL(ctx context.Context, url string) models.LinkCheck {
+	check := models.LinkCheck{CheckedAt: time.Now()}
+
+	method := http.MethodHead
+	if m.checkOpts.ContentFingerprint {
+		method = http.MethodGet
 	}
 
 	// Exécution de la requête
-	resp, err := client.Do(req)
+	resp, err := m.do(ctx, method, url)
+	if err == nil && method == http.MethodHead &&
+		(resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		drainBody(resp, m.maxResponseBytes)
+		method = http.MethodGet
+		resp, err = m.do(ctx, method, url)
+	}
+	check.LatencyMs = int(time.Since(check.CheckedAt).Milliseconds())
 	if err != nil {
-		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
-		return false
+		if ctx.Err() == nil {
+			m.logger.Info("Erreur d'accès à l'URL", "url", url, "error", err)
+		}
+		check.ErrorClass = classifyError(err)
+		return check
 	}
-	defer resp.Body.Close() // Fermeture du corps de la réponse
 
 	// Déterminer l'accessibilité basée sur le code de statut HTTP
-	return resp.StatusCode >= 200 && resp.StatusCode < 400
+	check.StatusCode = resp.StatusCode
+	check.FinalURL = resp.Request.URL.String()
+	check.Up = resp.StatusCode >= 200 && resp.StatusCode < 400
+	if !check.Up {
+		check.ErrorClass = ErrorClassHTTPStatus
+	}
+
+	if method == http.MethodHead {
+		drainBody(resp, m.maxResponseBytes) // Fermeture du corps de la réponse
+		return check
+	}
+	// Le serveur peut ignorer l'en-tête Range : seul le premier Ko est lu, le reste n'est pas téléchargé.
+	defer resp.Body.Close()
+	if check.Up {
+		head, err := io.ReadAll(io.LimitReader(resp.Body, fingerprintBytes))
+		if err != nil {
+			if ctx.Err() == nil {
+				m.logger.Info("Erreur de lecture du contenu de l'URL", "url", url, "error", err)
+			}
+			return check
+		}
+		sum := sha256.Sum256(head)
+		check.ContentHash = hex.EncodeToString(sum[:])
+	}
+	return check
+}
+
+// do envoie une requête 'method' vers url. Une requête GET ne demande que le premier Ko du contenu.
+func (m *UrlMonitor) do(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidURL, err)
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", fingerprintBytes-1))
+	}
+	return m.client.Do(req)
 }
 
 // formatState est une fonction utilitaire pour rendre l'état plus lisible dans les logs.
@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommandNotifier exécute une commande locale pour chaque événement. L'événement est fourni en JSON
+// sur l'entrée standard et résumé dans les variables d'environnement URLSHORTENER_EVENT,
+// URLSHORTENER_SHORT_CODE, URLSHORTENER_LONG_URL, URLSHORTENER_UP et URLSHORTENER_FINAL_URL.
+type CommandNotifier struct {
+	name    string
+	command []string // Programme suivi de ses arguments, exécuté sans shell
+	timeout time.Duration
+}
+
+// NewCommandNotifier crée un notifier exécutant 'command' avec un délai maximal 'timeout'.
+func NewCommandNotifier(name string, command []string, timeout time.Duration) (*CommandNotifier, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("notifier %q: empty command", name)
+	}
+	return &CommandNotifier{name: name, command: command, timeout: timeout}, nil
+}
+
+// Name implémente Notifier.
+func (n *CommandNotifier) Name() string { return n.name }
+
+// Notify implémente Notifier.
+func (n *CommandNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if n.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, n.command[0], n.command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"URLSHORTENER_EVENT="+event.Type,
+		"URLSHORTENER_SHORT_CODE="+event.ShortCode,
+		"URLSHORTENER_LONG_URL="+event.LongURL,
+		"URLSHORTENER_UP="+strconv.FormatBool(event.Up),
+		"URLSHORTENER_FINAL_URL="+event.FinalURL,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
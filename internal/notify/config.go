@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"urlshortener/internal/config"
+)
+
+// NewDispatcherFromConfig construit le Dispatcher décrit par la section 'notifications' de la
+// configuration. Il retourne nil si aucun notifier n'est configuré.
+func NewDispatcherFromConfig(cfg *config.Config) (*Dispatcher, error) {
+	nc := cfg.Notifications
+
+	var notifiers []Notifier
+	for _, w := range nc.Webhooks {
+		if w.URL == "" {
+			return nil, fmt.Errorf("webhook notifier %q: url is required", w.Name)
+		}
+		notifiers = append(notifiers, NewWebhookNotifier(w.Name, w.URL, w.Secret))
+	}
+	for _, s := range nc.Slack {
+		if s.WebhookURL == "" {
+			return nil, fmt.Errorf("slack notifier %q: webhook_url is required", s.Name)
+		}
+		notifiers = append(notifiers, NewSlackNotifier(s.Name, s.WebhookURL))
+	}
+	for _, e := range nc.Email {
+		if e.Host == "" || e.From == "" || len(e.To) == 0 {
+			return nil, fmt.Errorf("email notifier %q: host, from and to are required", e.Name)
+		}
+		port := e.Port
+		if port == 0 {
+			port = 25
+		}
+		notifiers = append(notifiers, NewEmailNotifier(e.Name, EmailOptions{
+			Host: e.Host, Port: port, Username: e.Username, Password: e.Password, From: e.From, To: e.To,
+		}))
+	}
+	for _, c := range nc.Commands {
+		n, err := NewCommandNotifier(c.Name, c.Command, time.Duration(c.TimeoutSeconds)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+
+	routes := make([]Route, len(nc.Routes))
+	for i, r := range nc.Routes {
+		tags := make([]string, len(r.Tags))
+		for j, tag := range r.Tags {
+			tags[j] = strings.ToLower(strings.TrimSpace(tag))
+		}
+		routes[i] = Route{Notifiers: r.Notifiers, Owners: r.Owners, Tags: tags}
+	}
+	return NewDispatcher(notifiers, routes, time.Duration(nc.CooldownMinutes)*time.Minute)
+}
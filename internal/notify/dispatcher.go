@@ -0,0 +1,227 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Route associe des notifiers aux liens d'un propriétaire ou portant une étiquette.
+// Une route sans propriétaire ni étiquette s'applique à tous les liens.
+type Route struct {
+	Notifiers []string // Noms des notifiers destinataires
+	Owners    []uint   // Identifiants de clés API propriétaires
+	Tags      []string // Étiquettes de liens
+}
+
+// matches indique si la route s'applique à l'événement.
+func (r Route) matches(event Event) bool {
+	if len(r.Owners) == 0 && len(r.Tags) == 0 {
+		return true
+	}
+	if event.OwnerKeyID != nil && slices.Contains(r.Owners, *event.OwnerKeyID) {
+		return true
+	}
+	for _, tag := range event.Tags {
+		if slices.Contains(r.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkState est l'historique des notifications d'un lien, utilisé pour la déduplication.
+type linkState struct {
+	notifiedUp bool      // Dernier état notifié
+	notifiedAt time.Time // Date de la dernière notification
+	pending    *Event    // Changement retenu pendant le délai de carence
+
+	contentNotifiedAt time.Time // Date de la dernière notification de changement de contenu
+}
+
+// Dispatcher route les changements d'état des liens vers les notifiers, en arrière-plan.
+// Pour qu'un lien instable ne multiplie pas les messages, un lien notifié ne l'est plus avant
+// 'cooldown' : les changements intermédiaires sont regroupés et seul l'état final est envoyé
+// à la fin du délai, s'il diffère du dernier état notifié. Les changements de contenu ne modifient
+// pas l'état notifié : ils sont envoyés au plus une fois par 'cooldown', les suivants sont ignorés.
+type Dispatcher struct {
+	notifiers map[string]Notifier
+	routes    []Route
+	cooldown  time.Duration
+	logger    *slog.Logger // Journal des notifications (component=notify)
+
+	events chan Event
+	links  map[uint]*linkState // Accédé uniquement par la goroutine de run
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// notifyTimeout borne la durée d'envoi d'un événement à un notifier.
+const notifyTimeout = 30 * time.Second
+
+// NewDispatcher crée un Dispatcher. Sans route, chaque notifier reçoit tous les événements.
+func NewDispatcher(notifiers []Notifier, routes []Route, cooldown time.Duration) (*Dispatcher, error) {
+	byName := make(map[string]Notifier, len(notifiers))
+	for _, n := range notifiers {
+		if n.Name() == "" {
+			return nil, fmt.Errorf("notifier name is required")
+		}
+		if _, exists := byName[n.Name()]; exists {
+			return nil, fmt.Errorf("duplicate notifier name %q", n.Name())
+		}
+		byName[n.Name()] = n
+	}
+	for _, route := range routes {
+		for _, name := range route.Notifiers {
+			if _, ok := byName[name]; !ok {
+				return nil, fmt.Errorf("route references unknown notifier %q", name)
+			}
+		}
+	}
+	return &Dispatcher{
+		notifiers: byName,
+		routes:    routes,
+		cooldown:  cooldown,
+		logger:    slog.With("component", "notify"),
+		events:    make(chan Event, 100),
+		links:     make(map[uint]*linkState),
+	}, nil
+}
+
+// Notify transmet un changement d'état sans bloquer l'appelant. Un Dispatcher nil ignore l'événement.
+func (d *Dispatcher) Notify(event Event) {
+	if d == nil {
+		return
+	}
+	select {
+	case d.events <- event:
+	default:
+		d.logger.Warn("File des notifications pleine, événement ignoré", "event", event.Type, "short_code", event.ShortCode)
+	}
+}
+
+// Start lance l'envoi des notifications dans une goroutine séparée.
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	d.wg.Add(1)
+	go d.run(ctx)
+}
+
+// Stop arrête l'envoi des notifications et attend la fin de l'envoi en cours.
+func (d *Dispatcher) Stop() {
+	if d == nil || d.cancel == nil {
+		return
+	}
+	d.cancel()
+	d.wg.Wait()
+}
+
+// run reçoit les événements et envoie, chaque seconde, ceux dont le délai de carence a expiré.
+func (d *Dispatcher) run(ctx context.Context) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-d.events:
+			d.handle(ctx, event)
+		case <-ticker.C:
+			d.flushPending(ctx)
+		}
+	}
+}
+
+// handle applique la déduplication à un événement, puis l'envoie ou le retient.
+func (d *Dispatcher) handle(ctx context.Context, event Event) {
+	state, known := d.links[event.LinkID]
+	if !known {
+		state = &linkState{}
+		d.links[event.LinkID] = state
+	}
+	if event.Type == EventLinkContentChanged {
+		if time.Since(state.contentNotifiedAt) < d.cooldown {
+			return
+		}
+		state.contentNotifiedAt = time.Now()
+		d.deliver(ctx, event)
+		return
+	}
+	if !state.notifiedAt.IsZero() {
+		if event.Up == state.notifiedUp {
+			// Retour à l'état déjà notifié : le changement retenu n'a plus lieu d'être.
+			state.pending = nil
+			return
+		}
+		if time.Since(state.notifiedAt) < d.cooldown {
+			state.pending = &event
+			return
+		}
+	}
+	d.send(ctx, state, event)
+}
+
+// flushPending envoie les changements retenus dont le délai de carence a expiré.
+func (d *Dispatcher) flushPending(ctx context.Context) {
+	for _, state := range d.links {
+		if state.pending != nil && time.Since(state.notifiedAt) >= d.cooldown {
+			event := *state.pending
+			state.pending = nil
+			d.send(ctx, state, event)
+		}
+	}
+}
+
+// send enregistre l'état notifié du lien puis transmet l'événement.
+func (d *Dispatcher) send(ctx context.Context, state *linkState, event Event) {
+	state.notifiedUp = event.Up
+	state.notifiedAt = time.Now()
+	state.pending = nil
+	d.deliver(ctx, event)
+}
+
+// deliver transmet l'événement aux notifiers des routes correspondantes.
+func (d *Dispatcher) deliver(ctx context.Context, event Event) {
+	for _, notifier := range d.recipients(event) {
+		sendCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		if err := notifier.Notify(sendCtx, event); err != nil {
+			d.logger.Error("Erreur lors de l'envoi d'une notification",
+				"event", event.Type, "short_code", event.ShortCode, "notifier", notifier.Name(), "error", err)
+		}
+		cancel()
+	}
+}
+
+// recipients retourne les notifiers concernés par un événement, sans doublon.
+func (d *Dispatcher) recipients(event Event) []Notifier {
+	if len(d.routes) == 0 {
+		all := make([]Notifier, 0, len(d.notifiers))
+		for _, n := range d.notifiers {
+			all = append(all, n)
+		}
+		return all
+	}
+
+	var recipients []Notifier
+	seen := make(map[string]bool)
+	for _, route := range d.routes {
+		if !route.matches(event) {
+			continue
+		}
+		for _, name := range route.Notifiers {
+			if !seen[name] {
+				seen[name] = true
+				recipients = append(recipients, d.notifiers[name])
+			}
+		}
+	}
+	return recipients
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailOptions configure un EmailNotifier.
+type EmailOptions struct {
+	Host     string
+	Port     int
+	Username string // Authentification PLAIN si renseigné
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailNotifier envoie l'événement par e-mail via un serveur SMTP.
+type EmailNotifier struct {
+	name string
+	opts EmailOptions
+}
+
+// NewEmailNotifier crée un notifier SMTP.
+func NewEmailNotifier(name string, opts EmailOptions) *EmailNotifier {
+	return &EmailNotifier{name: name, opts: opts}
+}
+
+// Name implémente Notifier.
+func (n *EmailNotifier) Name() string { return n.name }
+
+// emailTimeout borne l'échange SMTP lorsque ctx n'a pas d'échéance.
+const emailTimeout = 30 * time.Second
+
+// Notify implémente Notifier. La connexion et tout l'échange SMTP sont bornés par l'échéance de ctx,
+// et interrompus dès son annulation.
+func (n *EmailNotifier) Notify(ctx context.Context, event Event) error {
+	subject := fmt.Sprintf("[urlshortener] %s : %s", event.ShortCode, strings.ToUpper(stateLabel(event)))
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.opts.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.opts.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.At.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(event.Message() + "\r\n")
+
+	return n.send(ctx, []byte(msg.String()))
+}
+
+// send transmet msg au serveur SMTP, comme smtp.SendMail mais en respectant ctx : STARTTLS si le
+// serveur le propose, authentification PLAIN si un utilisateur est configuré.
+func (n *EmailNotifier) send(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(n.opts.Host, strconv.Itoa(n.opts.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error connecting to smtp server: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(emailTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// L'annulation de ctx ferme la connexion et débloque l'échange en cours.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, n.opts.Host)
+	if err != nil {
+		return fmt.Errorf("error starting smtp session: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.opts.Host}); err != nil {
+			return fmt.Errorf("error starting tls: %w", err)
+		}
+	}
+	if n.opts.Username != "" {
+		auth := smtp.PlainAuth("", n.opts.Username, n.opts.Password, n.opts.Host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+	if err := c.Mail(n.opts.From); err != nil {
+		return err
+	}
+	for _, to := range n.opts.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// stateLabel retourne le libellé de l'état décrit par un événement.
+func stateLabel(event Event) string {
+	if event.Type == EventLinkContentChanged {
+		return "contenu modifié"
+	}
+	if event.Up {
+		return "accessible"
+	}
+	return "inaccessible"
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Types d'événements envoyés aux notifiers.
+const (
+	EventLinkDown           = "link.down"
+	EventLinkUp             = "link.up"
+	EventLinkContentChanged = "link.content_changed" // Le début du contenu de l'URL a changé
+)
+
+// Event décrit un changement d'état ou de contenu de l'URL longue d'un lien détecté par le moniteur.
+type Event struct {
+	Type       string    `json:"type"`
+	LinkID     uint      `json:"link_id"`
+	ShortCode  string    `json:"short_code"`
+	LongURL    string    `json:"long_url"`
+	OwnerKeyID *uint     `json:"owner_key_id,omitempty"` // Clé API propriétaire du lien
+	Tags       []string  `json:"tags"`
+	Up         bool      `json:"up"`
+	StatusCode int       `json:"status_code,omitempty"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	FinalURL   string    `json:"final_url,omitempty"` // URL atteinte après les redirections
+	At         time.Time `json:"at"`
+}
+
+// Message retourne une description lisible de l'événement, utilisée par les notifiers textuels.
+func (e Event) Message() string {
+	if e.Type == EventLinkContentChanged {
+		return fmt.Sprintf("Le contenu du lien %s (%s) a changé (URL finale : %s).", e.ShortCode, e.LongURL, e.FinalURL)
+	}
+	if e.Up {
+		return fmt.Sprintf("Le lien %s (%s) est de nouveau ACCESSIBLE.", e.ShortCode, e.LongURL)
+	}
+	detail := e.ErrorClass
+	if e.StatusCode != 0 {
+		detail = fmt.Sprintf("HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("Le lien %s (%s) est INACCESSIBLE (%s).", e.ShortCode, e.LongURL, detail)
+}
+
+// Notifier transmet un événement vers un canal externe (webhook, e-mail...).
+type Notifier interface {
+	Name() string // Nom unique, référencé par les routes
+	Notify(ctx context.Context, event Event) error
+}
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// httpClient est partagé par les notifiers HTTP. Les URLs des webhooks étant fournies par
+// l'administrateur dans la configuration, elles peuvent viser le réseau interne.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// postJSON envoie 'body' en POST et retourne une erreur si la réponse n'est pas un succès.
+func postJSON(ctx context.Context, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// WebhookNotifier envoie l'événement en JSON à une URL. Si un secret est configuré, la requête est
+// signée : l'en-tête X-Signature-256 vaut "sha256=" suivi du HMAC-SHA256 hexadécimal de
+// "<X-Signature-Timestamp>.<corps>", ce qui permet au destinataire de vérifier l'origine
+// du message et de rejeter les rejeux.
+type WebhookNotifier struct {
+	name   string
+	url    string
+	secret string
+}
+
+// NewWebhookNotifier crée un notifier webhook JSON générique.
+func NewWebhookNotifier(name, url, secret string) *WebhookNotifier {
+	return &WebhookNotifier{name: name, url: url, secret: secret}
+}
+
+// Name implémente Notifier.
+func (n *WebhookNotifier) Name() string { return n.name }
+
+// Notify implémente Notifier.
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{}
+	if n.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers["X-Signature-Timestamp"] = timestamp
+		headers["X-Signature-256"] = "sha256=" + sign(n.secret, timestamp, body)
+	}
+	return postJSON(ctx, n.url, body, headers)
+}
+
+// sign calcule la signature HMAC-SHA256 d'un corps de webhook.
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SlackNotifier publie l'événement sur un webhook entrant compatible Slack (Slack, Mattermost, Rocket.Chat...).
+type SlackNotifier struct {
+	name       string
+	webhookURL string
+}
+
+// NewSlackNotifier crée un notifier pour un webhook entrant Slack.
+func NewSlackNotifier(name, webhookURL string) *SlackNotifier {
+	return &SlackNotifier{name: name, webhookURL: webhookURL}
+}
+
+// Name implémente Notifier.
+func (n *SlackNotifier) Name() string { return n.name }
+
+// Notify implémente Notifier.
+func (n *SlackNotifier) Notify(ctx context.Context, event Event) error {
+	icon := ":red_circle:"
+	switch {
+	case event.Type == EventLinkContentChanged:
+		icon = ":warning:"
+	case event.Up:
+		icon = ":large_green_circle:"
+	}
+	body, err := json.Marshal(map[string]string{"text": icon + " " + event.Message()})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, n.webhookURL, body, nil)
+}
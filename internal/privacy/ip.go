@@ -0,0 +1,23 @@
+package privacy
+
+import "net"
+
+// Longueurs de préfixe conservées lors de l'anonymisation des adresses IP.
+const (
+	ipv4PrefixBits = 24
+	ipv6PrefixBits = 48
+)
+
+// AnonymizeIP tronque une adresse IP à son préfixe réseau : /24 pour IPv4, /48 pour IPv6
+// (ex: 203.0.113.42 devient 203.0.113.0). Une valeur qui n'est pas une IP valide est
+// remplacée par une chaîne vide pour ne jamais conserver un identifiant inattendu.
+func AnonymizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(ipv4PrefixBits, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(ipv6PrefixBits, 128)).String()
+}
@@ -0,0 +1,48 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// visitorHashBytes est le nombre d'octets conservés de l'empreinte (32 caractères hexadécimaux).
+const visitorHashBytes = 16
+
+// VisitorHasher calcule une empreinte anonyme d'un visiteur à partir de son IP et de son User-Agent.
+// L'empreinte est un HMAC-SHA256 dont la clé dérive d'un sel secret et de la date du jour (UTC) :
+// un même visiteur a la même empreinte sur une journée, mais les empreintes de deux jours
+// différents ne peuvent pas être rapprochées, ni inversées sans le sel.
+type VisitorHasher struct {
+	salt []byte
+}
+
+// NewVisitorHasher crée un VisitorHasher à partir d'un sel secret.
+// Si le sel est vide, un sel aléatoire est généré : les empreintes ne sont alors cohérentes
+// que pour la durée de vie du processus.
+func NewVisitorHasher(salt string) (*VisitorHasher, error) {
+	if salt != "" {
+		return &VisitorHasher{salt: []byte(salt)}, nil
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("error generating visitor salt: %w", err)
+	}
+	return &VisitorHasher{salt: random}, nil
+}
+
+// Hash retourne l'empreinte du visiteur (ip, userAgent) pour le jour de 't'.
+func (h *VisitorHasher) Hash(ip, userAgent string, t time.Time) string {
+	dayKey := hmac.New(sha256.New, h.salt)
+	dayKey.Write([]byte(t.UTC().Format(time.DateOnly)))
+
+	mac := hmac.New(sha256.New, dayKey.Sum(nil))
+	mac.Write([]byte(ip))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+	return hex.EncodeToString(mac.Sum(nil)[:visitorHashBytes])
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit décrit un seau à jetons : Burst jetons au maximum, rechargés au rythme de Rate jetons par seconde.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute construit une Limit de 'requests' requêtes par minute avec une rafale de 'burst' requêtes.
+func PerMinute(requests, burst int) Limit {
+	return Limit{Rate: float64(requests) / 60, Burst: burst}
+}
+
+// Result est la décision du limiteur pour une requête.
+type Result struct {
+	Allowed    bool
+	Limit      int           // Taille du seau
+	Remaining  int           // Jetons restants après la requête
+	RetryAfter time.Duration // Attente avant le prochain jeton, si la requête est refusée
+	Reset      time.Duration // Attente avant que le seau soit de nouveau plein
+}
+
+// bucket est le seau à jetons d'un client.
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// Limiter applique une même Limit à un seau par clé (clé API, adresse IP...).
+// Les seaux inutilisés depuis idleTimeout sont supprimés par Start, ce qui borne la mémoire
+// consommée par des clients de passage.
+type Limiter struct {
+	limit       Limit
+	idleTimeout time.Duration
+	now         func() time.Time // Horloge, remplaçable dans les tests
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New crée un limiteur. Un seau inactif depuis idleTimeout est considéré plein et peut être supprimé.
+func New(limit Limit, idleTimeout time.Duration) *Limiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &Limiter{
+		limit:       limit,
+		idleTimeout: idleTimeout,
+		now:         time.Now,
+		buckets:     make(map[string]*bucket),
+	}
+}
+
+// Allow consomme un jeton du seau de 'key' s'il en reste un.
+func (l *Limiter) Allow(key string) Result {
+	now := l.now()
+	burst := float64(l.limit.Burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.limit.Rate)
+	}
+	b.lastSeen = now
+
+	result := Result{Limit: l.limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.timeToRefill(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = l.timeToRefill(burst - b.tokens)
+	return result
+}
+
+// timeToRefill retourne le temps nécessaire pour recharger 'tokens' jetons.
+func (l *Limiter) timeToRefill(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if l.limit.Rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+// EvictIdle supprime les seaux inutilisés depuis idleTimeout et retourne leur nombre.
+// Un tel seau est de toute façon plein à nouveau si idleTimeout couvre son temps de recharge.
+func (l *Limiter) EvictIdle() int {
+	cutoff := l.now().Add(-l.idleTimeout)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	evicted := 0
+	for key, b := range l.buckets {
+		if b.lastSeen.Before(cutoff) {
+			delete(l.buckets, key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Len retourne le nombre de seaux actuellement suivis.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// Start lance dans une goroutine séparée la suppression des seaux inactifs toutes les 'interval'.
+// Un intervalle nul ou négatif désactive la suppression.
+func (l *Limiter) Start(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.EvictIdle()
+			}
+		}
+	}()
+}
+
+// Stop arrête la suppression périodique des seaux inactifs.
+func (l *Limiter) Stop() {
+	if l.cancel == nil {
+		return
+	}
+	l.cancel()
+	l.wg.Wait()
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// fakeClock est une horloge avancée manuellement.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTestLimiter crée un limiteur dont l'horloge est contrôlée par le test.
+func newTestLimiter(limit Limit, idleTimeout time.Duration) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New(limit, idleTimeout)
+	l.now = clock.Now
+	return l, clock
+}
+
+func TestLimiterAllow(t *testing.T) {
+	// step est une requête, envoyée après avoir avancé l'horloge de 'advance'.
+	type step struct {
+		advance time.Duration
+		want    Result
+	}
+	tests := []struct {
+		name  string
+		limit Limit
+		steps []step
+	}{
+		{
+			name:  "burst then refusal",
+			limit: PerMinute(60, 3),
+			steps: []step{
+				{want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+				{want: Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 2 * time.Second}},
+				{want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second}},
+				{want: Result{Limit: 3, Remaining: 0, RetryAfter: time.Second, Reset: 3 * time.Second}},
+			},
+		},
+		{
+			name:  "partial refill",
+			limit: PerMinute(60, 1),
+			steps: []step{
+				{want: Result{Allowed: true, Limit: 1, Remaining: 0, Reset: time.Second}},
+				{advance: 250 * time.Millisecond, want: Result{Limit: 1, Remaining: 0, RetryAfter: 750 * time.Millisecond, Reset: 750 * time.Millisecond}},
+				{advance: 500 * time.Millisecond, want: Result{Limit: 1, Remaining: 0, RetryAfter: 250 * time.Millisecond, Reset: 250 * time.Millisecond}},
+				{advance: 250 * time.Millisecond, want: Result{Allowed: true, Limit: 1, Remaining: 0, Reset: time.Second}},
+			},
+		},
+		{
+			name:  "refill is capped at the burst",
+			limit: PerMinute(120, 2),
+			steps: []step{
+				{want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 500 * time.Millisecond}},
+				{want: Result{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Second}},
+				{advance: time.Hour, want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 500 * time.Millisecond}},
+			},
+		},
+		{
+			name:  "burst below one allows one request",
+			limit: Limit{Rate: 1, Burst: 0},
+			steps: []step{
+				{want: Result{Allowed: true, Limit: 1, Remaining: 0, Reset: time.Second}},
+				{want: Result{Limit: 1, Remaining: 0, RetryAfter: time.Second, Reset: time.Second}},
+			},
+		},
+		{
+			name:  "no refill without a rate",
+			limit: Limit{Rate: 0, Burst: 1},
+			steps: []step{
+				{want: Result{Allowed: true, Limit: 1, Remaining: 0, Reset: time.Duration(math.MaxInt64)}},
+				{advance: time.Hour, want: Result{Limit: 1, Remaining: 0, RetryAfter: time.Duration(math.MaxInt64), Reset: time.Duration(math.MaxInt64)}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLimiter(tt.limit, time.Hour)
+			for i, s := range tt.steps {
+				clock.Advance(s.advance)
+				if got := l.Allow("key"); got != s.want {
+					t.Fatalf("request %d: got %+v, want %+v", i+1, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter(PerMinute(60, 1), time.Hour)
+	if !l.Allow("a").Allowed {
+		t.Fatal("first request for a refused")
+	}
+	if l.Allow("a").Allowed {
+		t.Fatal("second request for a allowed")
+	}
+	if !l.Allow("b").Allowed {
+		t.Fatal("first request for b refused after a exhausted its bucket")
+	}
+}
+
+func TestLimiterEvictIdle(t *testing.T) {
+	tests := []struct {
+		name        string
+		advance     time.Duration // Temps écoulé depuis la dernière requête de "old"
+		wantEvicted int
+		wantKeys    int
+	}{
+		{"nothing idle", 30 * time.Second, 0, 2},
+		{"at the idle timeout", time.Minute, 0, 2},
+		{"one bucket idle", time.Minute + time.Nanosecond, 1, 1},
+		{"all buckets idle", 2 * time.Minute, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLimiter(PerMinute(60, 2), time.Minute)
+			l.Allow("old")
+			clock.Advance(30 * time.Second)
+			l.Allow("recent")
+			clock.Advance(tt.advance - 30*time.Second)
+
+			if evicted := l.EvictIdle(); evicted != tt.wantEvicted {
+				t.Fatalf("EvictIdle() = %d, want %d", evicted, tt.wantEvicted)
+			}
+			if n := l.Len(); n != tt.wantKeys {
+				t.Fatalf("Len() = %d, want %d", n, tt.wantKeys)
+			}
+
+			// Un seau supprimé repart plein.
+			if got := l.Allow("old"); !got.Allowed || got.Remaining != 1 {
+				t.Fatalf("request for old after eviction: got %+v, want allowed with 1 remaining", got)
+			}
+		})
+	}
+}
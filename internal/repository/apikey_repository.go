@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"time"
+
+	"urlshortener/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository définit les méthodes d'accès aux données pour les clés API.
+type APIKeyRepository interface {
+	CreateAPIKey(key *models.APIKey) error
+	GetAPIKeyByHash(hash string) (*models.APIKey, error)
+	ListAPIKeys() ([]models.APIKey, error)
+	RevokeAPIKey(id uint, at time.Time) error
+	TouchAPIKey(id uint, at time.Time) error
+}
+
+// GormAPIKeyRepository est l'implémentation de APIKeyRepository utilisant GORM.
+type GormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository crée et retourne une nouvelle instance de GormAPIKeyRepository.
+func NewAPIKeyRepository(db *gorm.DB) *GormAPIKeyRepository {
+	return &GormAPIKeyRepository{db: db}
+}
+
+// CreateAPIKey insère une nouvelle clé API.
+func (r *GormAPIKeyRepository) CreateAPIKey(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// GetAPIKeyByHash récupère une clé API (révoquée ou non) par l'empreinte de sa valeur.
+// Il renvoie gorm.ErrRecordNotFound si aucune clé ne correspond.
+func (r *GormAPIKeyRepository) GetAPIKeyByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("hash = ?", hash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeys retourne toutes les clés API, de la plus ancienne à la plus récente.
+func (r *GormAPIKeyRepository) ListAPIKeys() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.db.Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marque une clé comme révoquée. Il renvoie gorm.ErrRecordNotFound si aucune clé
+// active ne porte cet identifiant.
+func (r *GormAPIKeyRepository) RevokeAPIKey(id uint, at time.Time) error {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey enregistre la date de dernière utilisation d'une clé.
+func (r *GormAPIKeyRepository) TouchAPIKey(id uint, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
+	"urlshortener/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LinkCacheOptions configure le cache de CachedLinkRepository.
+type LinkCacheOptions struct {
+	Size        int           // Nombre maximum d'entrées (positives et négatives)
+	TTL         time.Duration // Durée de vie d'un lien en cache
+	NegativeTTL time.Duration // Durée de vie d'un code inconnu en cache (0 = pas de cache négatif)
+}
+
+// LinkCacheStats regroupe les compteurs du cache de liens.
+type LinkCacheStats struct {
+	Hits         int64 `json:"hits"`          // Liens servis depuis le cache
+	NegativeHits int64 `json:"negative_hits"` // Codes inconnus servis depuis le cache
+	Misses       int64 `json:"misses"`        // Recherches transmises à la base de données
+	Evictions    int64 `json:"evictions"`     // Entrées évincées faute de place
+	Size         int   `json:"size"`          // Nombre d'entrées actuellement en cache
+}
+
+// linkCacheEntry est une entrée du cache. Un lien nil représente un code inconnu (cache négatif).
+type linkCacheEntry struct {
+	shortCode string
+	link      *models.Link
+	expiresAt time.Time
+}
+
+// CachedLinkRepository enveloppe un LinkRepository avec un cache LRU borné à durée de vie limitée
+// pour GetLinkByShortCode, utilisé par chaque redirection. Les écritures passant par ce dépôt
+// invalident le code concerné ; celles effectuées par un autre processus (CLI) sont visibles
+// au plus tard après l'expiration de l'entrée.
+type CachedLinkRepository struct {
+	LinkRepository // Dépôt sous-jacent, utilisé directement pour les méthodes non mises en cache
+
+	opts LinkCacheOptions
+	now  func() time.Time // Horloge, remplaçable dans les tests
+
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List // Entrées de la plus récemment utilisée (devant) à la plus ancienne
+	generation uint64     // Incrémentée à chaque invalidation, voir GetLinkByShortCode
+	stats      LinkCacheStats
+}
+
+// NewCachedLinkRepository crée un cache de liens devant 'repo'.
+func NewCachedLinkRepository(repo LinkRepository, opts LinkCacheOptions) *CachedLinkRepository {
+	if opts.Size < 1 {
+		opts.Size = 1
+	}
+	return &CachedLinkRepository{
+		LinkRepository: repo,
+		opts:           opts,
+		now:            time.Now,
+		entries:        make(map[string]*list.Element),
+		lru:            list.New(),
+	}
+}
+
+// GetLinkByShortCode retourne le lien depuis le cache s'il y est encore valide, sinon l'interroge
+// dans le dépôt sous-jacent et le met en cache. Un code inconnu est mis en cache négatif et
+// retourne gorm.ErrRecordNotFound. Le lien retourné est une copie que l'appelant peut modifier.
+func (c *CachedLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[shortCode]; ok {
+		entry := elem.Value.(*linkCacheEntry)
+		if c.now().Before(entry.expiresAt) {
+			c.lru.MoveToFront(elem)
+			if entry.link == nil {
+				c.stats.NegativeHits++
+				c.mu.Unlock()
+				return nil, gorm.ErrRecordNotFound
+			}
+			c.stats.Hits++
+			link := *entry.link
+			c.mu.Unlock()
+			return &link, nil
+		}
+		c.removeLocked(elem)
+	}
+	c.stats.Misses++
+	generation := c.generation
+	c.mu.Unlock()
+
+	link, err := c.LinkRepository.GetLinkByShortCode(shortCode)
+	switch {
+	case err == nil:
+		cached := *link
+		c.store(shortCode, &cached, c.opts.TTL, generation)
+	case errors.Is(err, gorm.ErrRecordNotFound) && c.opts.NegativeTTL > 0:
+		c.store(shortCode, nil, c.opts.NegativeTTL, generation)
+	}
+	return link, err
+}
+
+// CreateLink crée le lien et retire du cache une éventuelle entrée négative pour son code.
+func (c *CachedLinkRepository) CreateLink(link *models.Link) error {
+	err := c.LinkRepository.CreateLink(link)
+	c.Invalidate(link.ShortCode)
+	return err
+}
+
+// UpdateLink enregistre le lien et l'invalide dans le cache.
+func (c *CachedLinkRepository) UpdateLink(link *models.Link) error {
+	err := c.LinkRepository.UpdateLink(link)
+	c.Invalidate(link.ShortCode)
+	return err
+}
+
+// DeleteLink supprime le lien et l'invalide dans le cache.
+func (c *CachedLinkRepository) DeleteLink(link *models.Link) error {
+	err := c.LinkRepository.DeleteLink(link)
+	c.Invalidate(link.ShortCode)
+	return err
+}
+
+// RestoreLink restaure le lien et retire l'entrée négative correspondante du cache.
+func (c *CachedLinkRepository) RestoreLink(shortCode string, ownerKeyID *uint) (*models.Link, error) {
+	link, err := c.LinkRepository.RestoreLink(shortCode, ownerKeyID)
+	c.Invalidate(shortCode)
+	return link, err
+}
+
+// ReserveClick décompte une redirection du budget du lien et reporte le résultat sur l'entrée en
+// cache, pour que l'épuisement du budget soit visible sans attendre son expiration.
+func (c *CachedLinkRepository) ReserveClick(link *models.Link) (bool, error) {
+	ok, err := c.LinkRepository.ReserveClick(link)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Une lecture commencée avant le décompte ne doit pas remettre en cache l'ancien compteur.
+	c.generation++
+	if elem, found := c.entries[link.ShortCode]; found {
+		if cached := elem.Value.(*linkCacheEntry).link; cached != nil && cached.ID == link.ID && cached.MaxClicks != nil {
+			if ok {
+				cached.ClicksUsed++
+			} else {
+				cached.ClicksUsed = *cached.MaxClicks
+			}
+		}
+	}
+	return ok, nil
+}
+
+// Invalidate retire un code du cache.
+func (c *CachedLinkRepository) Invalidate(shortCode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Une lecture commencée avant l'invalidation ne doit pas remettre en cache une valeur périmée.
+	c.generation++
+	if elem, ok := c.entries[shortCode]; ok {
+		c.removeLocked(elem)
+	}
+}
+
+// Stats retourne une copie des compteurs du cache.
+func (c *CachedLinkRepository) Stats() LinkCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
+
+// store met en cache le résultat d'une lecture, sauf si une invalidation a eu lieu depuis son début.
+func (c *CachedLinkRepository) store(shortCode string, link *models.Link, ttl time.Duration, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	entry := &linkCacheEntry{shortCode: shortCode, link: link, expiresAt: c.now().Add(ttl)}
+	if elem, ok := c.entries[shortCode]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[shortCode] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.opts.Size {
+		c.removeLocked(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// removeLocked retire une entrée du cache.
+func (c *CachedLinkRepository) removeLocked(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*linkCacheEntry).shortCode)
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"urlshortener/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// fakeLinkRepository est un LinkRepository en mémoire qui compte les lectures par code court.
+// Si block est défini, chaque lecture signale son début sur started puis attend release.
+type fakeLinkRepository struct {
+	LinkRepository
+
+	mu    sync.Mutex
+	links map[string]models.Link
+	loads int
+
+	block   bool
+	started chan struct{}
+	release chan struct{}
+}
+
+func newFakeLinkRepository(codes ...string) *fakeLinkRepository {
+	repo := &fakeLinkRepository{links: make(map[string]models.Link)}
+	for i, code := range codes {
+		repo.links[code] = models.Link{ID: uint(i + 1), ShortCode: code, LongURL: "https://example.com/" + code}
+	}
+	return repo
+}
+
+func (r *fakeLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	r.mu.Lock()
+	r.loads++
+	link, ok := r.links[shortCode]
+	block := r.block
+	r.mu.Unlock()
+
+	// La valeur est lue avant l'attente : elle est périmée si une écriture a lieu entre-temps.
+	if block {
+		r.started <- struct{}{}
+		<-r.release
+	}
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &link, nil
+}
+
+func (r *fakeLinkRepository) CreateLink(link *models.Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.links[link.ShortCode] = *link
+	return nil
+}
+
+func (r *fakeLinkRepository) UpdateLink(link *models.Link) error {
+	return r.CreateLink(link)
+}
+
+func (r *fakeLinkRepository) ReserveClick(link *models.Link) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.links[link.ShortCode]
+	if !ok || stored.MaxClicks == nil || stored.ClicksUsed >= *stored.MaxClicks {
+		return false, nil
+	}
+	stored.ClicksUsed++
+	r.links[link.ShortCode] = stored
+	return true, nil
+}
+
+func (r *fakeLinkRepository) loadCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.loads
+}
+
+// lookup interroge le cache et retourne l'URL longue, ou "" pour un code inconnu.
+func lookup(t *testing.T, cache *CachedLinkRepository, shortCode string) string {
+	t.Helper()
+	link, err := cache.GetLinkByShortCode(shortCode)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ""
+	}
+	if err != nil {
+		t.Fatalf("GetLinkByShortCode(%q): %v", shortCode, err)
+	}
+	return link.LongURL
+}
+
+func TestCachedLinkRepositoryInvalidateDuringLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial []string // Codes présents avant la lecture
+		write   func(cache *CachedLinkRepository) error
+		wantURL string // URL servie après l'écriture
+	}{
+		{
+			name:    "update of a cached link",
+			initial: []string{"abc"},
+			write: func(cache *CachedLinkRepository) error {
+				return cache.UpdateLink(&models.Link{ID: 1, ShortCode: "abc", LongURL: "https://example.com/updated"})
+			},
+			wantURL: "https://example.com/updated",
+		},
+		{
+			name: "creation of a code being looked up as unknown",
+			write: func(cache *CachedLinkRepository) error {
+				return cache.CreateLink(&models.Link{ID: 1, ShortCode: "abc", LongURL: "https://example.com/created"})
+			},
+			wantURL: "https://example.com/created",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeLinkRepository(tt.initial...)
+			repo.block = true
+			repo.started = make(chan struct{})
+			repo.release = make(chan struct{})
+			cache := NewCachedLinkRepository(repo, LinkCacheOptions{Size: 10, TTL: time.Hour, NegativeTTL: time.Hour})
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				cache.GetLinkByShortCode("abc")
+			}()
+
+			// L'écriture a lieu pendant que la lecture est en cours avec l'ancienne valeur.
+			<-repo.started
+			if err := tt.write(cache); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			close(repo.release)
+			<-done
+
+			// La lecture commencée avant l'invalidation ne doit pas avoir remis l'ancienne valeur en cache.
+			repo.mu.Lock()
+			repo.block = false
+			repo.mu.Unlock()
+			if got := lookup(t, cache, "abc"); got != tt.wantURL {
+				t.Fatalf("URL after write = %q, want %q", got, tt.wantURL)
+			}
+			if loads := repo.loadCount(); loads != 2 {
+				t.Fatalf("%d load(s) from the repository, want 2", loads)
+			}
+		})
+	}
+}
+
+func TestCachedLinkRepositoryTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		opts     LinkCacheOptions
+		wantURL  string
+		wantHits func(stats LinkCacheStats) int64
+		cached   time.Duration // Durée pendant laquelle le résultat est servi depuis le cache
+	}{
+		{
+			name:     "known link",
+			code:     "abc",
+			opts:     LinkCacheOptions{Size: 10, TTL: time.Minute, NegativeTTL: time.Second},
+			wantURL:  "https://example.com/abc",
+			wantHits: func(stats LinkCacheStats) int64 { return stats.Hits },
+			cached:   time.Minute,
+		},
+		{
+			name:     "unknown code",
+			code:     "zzz",
+			opts:     LinkCacheOptions{Size: 10, TTL: time.Minute, NegativeTTL: time.Second},
+			wantHits: func(stats LinkCacheStats) int64 { return stats.NegativeHits },
+			cached:   time.Second,
+		},
+		{
+			name:     "unknown code without negative cache",
+			code:     "zzz",
+			opts:     LinkCacheOptions{Size: 10, TTL: time.Minute},
+			wantHits: func(stats LinkCacheStats) int64 { return stats.NegativeHits },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeLinkRepository("abc")
+			cache := NewCachedLinkRepository(repo, tt.opts)
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			cache.now = func() time.Time { return now }
+
+			if got := lookup(t, cache, tt.code); got != tt.wantURL {
+				t.Fatalf("URL = %q, want %q", got, tt.wantURL)
+			}
+
+			// Juste avant l'expiration, le résultat vient du cache ; à l'expiration, du dépôt.
+			wantLoads := 1
+			if tt.cached == 0 {
+				wantLoads = 2
+			} else {
+				now = now.Add(tt.cached - time.Nanosecond)
+			}
+			if got := lookup(t, cache, tt.code); got != tt.wantURL {
+				t.Fatalf("URL before expiry = %q, want %q", got, tt.wantURL)
+			}
+			if loads := repo.loadCount(); loads != wantLoads {
+				t.Fatalf("%d load(s) before expiry, want %d", loads, wantLoads)
+			}
+
+			now = now.Add(time.Nanosecond)
+			if got := lookup(t, cache, tt.code); got != tt.wantURL {
+				t.Fatalf("URL after expiry = %q, want %q", got, tt.wantURL)
+			}
+			if loads := repo.loadCount(); loads != wantLoads+1 {
+				t.Fatalf("%d load(s) after expiry, want %d", loads, wantLoads+1)
+			}
+
+			stats := cache.Stats()
+			if hits := tt.wantHits(stats); hits != int64(2-wantLoads) {
+				t.Fatalf("hits = %d, want %d", hits, 2-wantLoads)
+			}
+			if stats.Misses != int64(wantLoads+1) {
+				t.Fatalf("misses = %d, want %d", stats.Misses, wantLoads+1)
+			}
+		})
+	}
+}
+
+func TestCachedLinkRepositoryEviction(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          int
+		lookups       []string // Codes interrogés dans l'ordre
+		wantCached    []string // Codes servis ensuite depuis le cache
+		wantEvicted   []string // Codes relus depuis le dépôt
+		wantEvictions int64
+	}{
+		{
+			name:          "least recently used is evicted",
+			size:          2,
+			lookups:       []string{"a", "b", "a", "c"},
+			wantCached:    []string{"a", "c"},
+			wantEvicted:   []string{"b"},
+			wantEvictions: 1,
+		},
+		{
+			name:          "negative entries count towards the size",
+			size:          2,
+			lookups:       []string{"a", "x", "y"},
+			wantCached:    []string{"x", "y"},
+			wantEvicted:   []string{"a"},
+			wantEvictions: 1,
+		},
+		{
+			name:          "size below one keeps one entry",
+			size:          0,
+			lookups:       []string{"a", "b"},
+			wantCached:    []string{"b"},
+			wantEvicted:   []string{"a"},
+			wantEvictions: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeLinkRepository("a", "b", "c")
+			cache := NewCachedLinkRepository(repo, LinkCacheOptions{Size: tt.size, TTL: time.Hour, NegativeTTL: time.Hour})
+			for _, code := range tt.lookups {
+				lookup(t, cache, code)
+			}
+			stats := cache.Stats()
+			if stats.Evictions != tt.wantEvictions {
+				t.Fatalf("evictions = %d, want %d", stats.Evictions, tt.wantEvictions)
+			}
+			if stats.Size != len(tt.wantCached) {
+				t.Fatalf("size = %d, want %d", stats.Size, len(tt.wantCached))
+			}
+
+			for _, code := range tt.wantCached {
+				loads := repo.loadCount()
+				lookup(t, cache, code)
+				if repo.loadCount() != loads {
+					t.Errorf("%q was loaded from the repository, want a cache hit", code)
+				}
+			}
+			for _, code := range tt.wantEvicted {
+				loads := repo.loadCount()
+				lookup(t, cache, code)
+				if repo.loadCount() != loads+1 {
+					t.Errorf("%q was served from the cache, want it evicted", code)
+				}
+			}
+		})
+	}
+}
+
+func TestCachedLinkRepositoryReserveClick(t *testing.T) {
+	repo := newFakeLinkRepository()
+	maxClicks := 2
+	repo.links["abc"] = models.Link{ID: 1, ShortCode: "abc", LongURL: "https://example.com", MaxClicks: &maxClicks}
+	cache := NewCachedLinkRepository(repo, LinkCacheOptions{Size: 10, TTL: time.Hour})
+
+	// Chaque réservation est reportée sur l'entrée en cache, sans relire le dépôt.
+	for i, want := range []bool{true, true, false} {
+		link, err := cache.GetLinkByShortCode("abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expired := link.HasExpired(time.Now()); expired != !want {
+			t.Fatalf("reservation %d: cached link expired = %v, want %v", i+1, expired, !want)
+		}
+		ok, err := cache.ReserveClick(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != want {
+			t.Fatalf("reservation %d = %v, want %v", i+1, ok, want)
+		}
+	}
+	if loads := repo.loadCount(); loads != 1 {
+		t.Fatalf("%d load(s) from the repository, want 1", loads)
+	}
+}
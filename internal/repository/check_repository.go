@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"urlshortener/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CheckRepository définit les méthodes d'accès à l'historique des vérifications du moniteur.
+type CheckRepository interface {
+	CreateCheck(check *models.LinkCheck) error
+	GetLatestCheck(linkID uint) (*models.LinkCheck, error)
+	GetLatestStates() (map[uint]bool, error)                                    // Dernier état connu de chaque lien, utilisé au démarrage du moniteur
+	GetLatestFingerprints() (map[uint]string, error)                            // Dernière empreinte de contenu de chaque lien, utilisée au démarrage du moniteur
+	GetConsecutiveFailures() (map[uint]int, error)                              // Échecs consécutifs en cours de chaque lien, utilisés au démarrage du moniteur
+	GetLastStateChange(linkID uint, up bool) (*time.Time, error)                // Début de la série de vérifications dans l'état 'up'
+	CountChecksSince(linkID uint, since time.Time) (total, up int64, err error) // Utilisé pour le calcul de la disponibilité
+	DeleteChecksBefore(cutoff time.Time) (int64, error)
+}
+
+// GormCheckRepository est l'implémentation de CheckRepository utilisant GORM.
+type GormCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewCheckRepository crée et retourne une nouvelle instance de GormCheckRepository.
+func NewCheckRepository(db *gorm.DB) *GormCheckRepository {
+	return &GormCheckRepository{db: db}
+}
+
+// CreateCheck enregistre le résultat d'une vérification.
+func (r *GormCheckRepository) CreateCheck(check *models.LinkCheck) error {
+	return r.db.Create(check).Error
+}
+
+// GetLatestCheck retourne la dernière vérification d'un lien, ou gorm.ErrRecordNotFound s'il n'a jamais été vérifié.
+func (r *GormCheckRepository) GetLatestCheck(linkID uint) (*models.LinkCheck, error) {
+	var check models.LinkCheck
+	err := r.db.Where("link_id = ?", linkID).Order("checked_at DESC").Order("id DESC").First(&check).Error
+	if err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+// GetLatestStates retourne l'état de la dernière vérification de chaque lien vérifié au moins une fois.
+func (r *GormCheckRepository) GetLatestStates() (map[uint]bool, error) {
+	var checks []models.LinkCheck
+	latest := r.db.Model(&models.LinkCheck{}).Select("MAX(id)").Group("link_id")
+	if err := r.db.Select("link_id", "up").Where("id IN (?)", latest).Find(&checks).Error; err != nil {
+		return nil, err
+	}
+	states := make(map[uint]bool, len(checks))
+	for _, check := range checks {
+		states[check.LinkID] = check.Up
+	}
+	return states, nil
+}
+
+// GetLatestFingerprints retourne l'empreinte de contenu la plus récente de chaque lien dont le contenu a été lu au moins une fois.
+func (r *GormCheckRepository) GetLatestFingerprints() (map[uint]string, error) {
+	var checks []models.LinkCheck
+	latest := r.db.Model(&models.LinkCheck{}).Select("MAX(id)").Where("content_hash <> ''").Group("link_id")
+	if err := r.db.Select("link_id", "content_hash").Where("id IN (?)", latest).Find(&checks).Error; err != nil {
+		return nil, err
+	}
+	fingerprints := make(map[uint]string, len(checks))
+	for _, check := range checks {
+		fingerprints[check.LinkID] = check.ContentHash
+	}
+	return fingerprints, nil
+}
+
+// GetConsecutiveFailures retourne, pour chaque lien actuellement inaccessible, le nombre de vérifications
+// en échec enregistrées depuis sa dernière vérification réussie.
+func (r *GormCheckRepository) GetConsecutiveFailures() (map[uint]int, error) {
+	var rows []struct {
+		LinkID   uint
+		Failures int
+	}
+	lastUp := r.db.Model(&models.LinkCheck{}).
+		Select("COALESCE(MAX(last_up.id), 0)").
+		Table("link_checks AS last_up").
+		Where("last_up.link_id = link_checks.link_id AND last_up.up = ?", true)
+	err := r.db.Model(&models.LinkCheck{}).
+		Select("link_id, COUNT(*) AS failures").
+		Where("up = ? AND id > (?)", false, lastUp).
+		Group("link_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	failures := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		failures[row.LinkID] = row.Failures
+	}
+	return failures, nil
+}
+
+// GetLastStateChange retourne la date de la première vérification de la série en cours, c'est-à-dire
+// la première vérification dans l'état 'up' qui suit la dernière vérification dans l'état opposé.
+// Si le lien a toujours été dans l'état 'up', c'est la date de sa première vérification.
+func (r *GormCheckRepository) GetLastStateChange(linkID uint, up bool) (*time.Time, error) {
+	query := r.db.Model(&models.LinkCheck{}).Where("link_id = ? AND up = ?", linkID, up)
+
+	var previous models.LinkCheck
+	err := r.db.Where("link_id = ? AND up = ?", linkID, !up).Order("checked_at DESC").First(&previous).Error
+	if err == nil {
+		query = query.Where("checked_at > ?", previous.CheckedAt)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var first models.LinkCheck
+	if err := query.Order("checked_at").First(&first).Error; err != nil {
+		return nil, err
+	}
+	return &first.CheckedAt, nil
+}
+
+// CountChecksSince compte les vérifications d'un lien depuis 'since', au total et dans l'état accessible.
+func (r *GormCheckRepository) CountChecksSince(linkID uint, since time.Time) (total, up int64, err error) {
+	var counts struct {
+		Total int64
+		Up    int64
+	}
+	err = r.db.Model(&models.LinkCheck{}).
+		Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN up THEN 1 ELSE 0 END), 0) AS up").
+		Where("link_id = ? AND checked_at >= ?", linkID, since).
+		Scan(&counts).Error
+	return counts.Total, counts.Up, err
+}
+
+// DeleteChecksBefore supprime les vérifications antérieures à 'cutoff' et retourne leur nombre.
+func (r *GormCheckRepository) DeleteChecksBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("checked_at < ?", cutoff).Delete(&models.LinkCheck{})
+	return result.RowsAffected, result.Error
+}
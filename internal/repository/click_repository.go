@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"time"
+
 	"urlshortener/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ClickRepository est une interface qui définit les méthodes d'accès aux données
@@ -11,7 +14,47 @@ import (
 // de rester indépendante de l'implémentation spécifique de la base de données.
 type ClickRepository interface {
 	CreateClick(click *models.Click) error
-	CountClicksByLinkID(linkID uint) (int, error) // Utilisé par LinkService pour les stats
+	CreateClicks(clicks []*models.Click) error                                                   // Insertion par lots, utilisée par les workers
+	CountClicksByLinkID(linkID uint, filter ClickFilter) (int, error)                            // Utilisé par LinkService pour les stats
+	CountFallbackClicks(linkID uint) (int64, error)                                              // Redirections servies vers l'URL de repli
+	GetClickTimestamps(linkID uint, from, to time.Time, filter ClickFilter) ([]time.Time, error) // Utilisé pour les séries temporelles
+	GetClickBreakdown(linkID uint, dimension ClickDimension, limit int, filter ClickFilter) ([]BreakdownEntry, error)
+	ForEachVisitorHash(linkID uint, from, to time.Time, filter ClickFilter, fn func(timestamp time.Time, visitorHash string) error) error
+	CountClicksBefore(cutoff time.Time) (int64, error)     // Utilisé par la politique de rétention
+	DeleteClicksBefore(cutoff time.Time) (int64, error)    // Utilisé par la politique de rétention
+	AggregateClicksBefore(cutoff time.Time) (int64, error) // Utilisé par la politique de rétention
+}
+
+// ClickFilter regroupe les critères communs aux requêtes statistiques sur les clics.
+// La valeur zéro correspond au comportement par défaut des statistiques (robots exclus).
+type ClickFilter struct {
+	IncludeBots bool // Inclure les clics attribués à des robots
+}
+
+// apply ajoute les conditions du filtre à une requête sur la table 'clicks'.
+func (f ClickFilter) apply(query *gorm.DB) *gorm.DB {
+	if !f.IncludeBots {
+		query = query.Where("is_bot = ?", false)
+	}
+	return query
+}
+
+// ClickDimension est un axe de regroupement des clics. Sa valeur est le nom de la colonne SQL
+// correspondante : seules les constantes ci-dessous doivent être utilisées.
+type ClickDimension string
+
+// Axes de regroupement disponibles pour GetClickBreakdown.
+const (
+	DimensionReferrer ClickDimension = "referrer_host"
+	DimensionBrowser  ClickDimension = "browser"
+	DimensionOS       ClickDimension = "os"
+	DimensionDevice   ClickDimension = "device"
+)
+
+// BreakdownEntry représente le nombre de clics pour une valeur d'un axe de regroupement.
+type BreakdownEntry struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
 }
 
 // GormClickRepository est l'implémentation de l'interface ClickRepository utilisant GORM.
@@ -31,14 +74,229 @@ func (r *GormClickRepository) CreateClick(click *models.Click) error {
 	return r.db.Create(click).Error
 }
 
+// maxClicksPerInsert borne le nombre de lignes d'une requête INSERT afin de rester sous la limite
+// de paramètres liés de la base de données (32766 pour SQLite).
+const maxClicksPerInsert = 500
+
+// CreateClicks insère plusieurs clics avec une requête INSERT multi-lignes.
+// Les lots dépassant maxClicksPerInsert sont découpés et écrits dans une même transaction.
+func (r *GormClickRepository) CreateClicks(clicks []*models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(clicks, maxClicksPerInsert).Error
+}
+
 // CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
 // Cette méthode est utilisée pour fournir des statistiques pour une URL courte.
-func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
-	var count int64 // GORM retourne un int64 pour les décomptes
+func (r *GormClickRepository) CountClicksByLinkID(linkID uint, filter ClickFilter) (int, error) {
 	// TODO : Utiliser GORM pour compter les enregistrements dans la table 'clicks'
 	// où 'LinkID' correspond à l'ID de lien fourni.
-	if err := r.db.Model(&models.Click{}).Where("ID = ?", linkID).Count(&count).Error; err != nil {
+	// Les clics agrégés par la politique de rétention sont ajoutés au décompte.
+	count, err := countClicks(r.db, linkID, filter)
+	if err != nil {
 		return 0, err // Retourne 0 et l'erreur si la requête échoue
 	}
-	return int(count), nil 
+	return int(count), nil
+}
+
+// countClicks compte les clics d'un lien, détaillés (table 'clicks') et agrégés (table 'click_aggregates').
+// Partagée par les dépôts de liens et de clics.
+func countClicks(db *gorm.DB, linkID uint, filter ClickFilter) (int64, error) {
+	var count int64
+	if err := filter.apply(db.Model(&models.Click{}).Where("link_id = ?", linkID)).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	var aggregated struct {
+		Clicks    int64
+		BotClicks int64
+	}
+	err := db.Model(&models.ClickAggregate{}).
+		Select("COALESCE(SUM(clicks), 0) AS clicks, COALESCE(SUM(bot_clicks), 0) AS bot_clicks").
+		Where("link_id = ?", linkID).
+		Scan(&aggregated).Error
+	if err != nil {
+		return 0, err
+	}
+
+	count += aggregated.Clicks
+	if filter.IncludeBots {
+		count += aggregated.BotClicks
+	}
+	return count, nil
+}
+
+// CountFallbackClicks compte les redirections d'un lien servies vers son URL de repli, robots compris,
+// en ajoutant celles conservées dans les agrégats de la politique de rétention.
+func (r *GormClickRepository) CountFallbackClicks(linkID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Click{}).Where("link_id = ? AND fallback = ?", linkID, true).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	var aggregated int64
+	err := r.db.Model(&models.ClickAggregate{}).
+		Select("COALESCE(SUM(fallback_clicks), 0)").
+		Where("link_id = ?", linkID).
+		Scan(&aggregated).Error
+	if err != nil {
+		return 0, err
+	}
+	return count + aggregated, nil
+}
+
+// GetClickTimestamps retourne les horodatages des clics d'un lien compris dans l'intervalle [from, to).
+// Le regroupement par période est fait côté Go pour rester indépendant du moteur SQL
+// et respecter le fuseau horaire demandé.
+func (r *GormClickRepository) GetClickTimestamps(linkID uint, from, to time.Time, filter ClickFilter) ([]time.Time, error) {
+	var timestamps []time.Time
+	// Les horodatages sont enregistrés dans le fuseau local du serveur : on compare dans ce même fuseau.
+	query := r.db.Model(&models.Click{}).
+		Where("link_id = ? AND timestamp >= ? AND timestamp < ?", linkID, from.Local(), to.Local())
+	err := filter.apply(query).
+		Order("timestamp").
+		Pluck("timestamp", &timestamps).Error
+	if err != nil {
+		return nil, err
+	}
+	return timestamps, nil
+}
+
+// GetClickBreakdown retourne les valeurs les plus fréquentes de l'axe 'dimension' pour un lien,
+// triées par nombre de clics décroissant et limitées à 'limit' entrées.
+func (r *GormClickRepository) GetClickBreakdown(linkID uint, dimension ClickDimension, limit int, filter ClickFilter) ([]BreakdownEntry, error) {
+	// COALESCE regroupe les clics antérieurs à l'ajout de la colonne (NULL) avec les valeurs vides.
+	column := "COALESCE(" + string(dimension) + ", '')"
+
+	var entries []BreakdownEntry
+	query := r.db.Model(&models.Click{}).
+		Select(column+" AS value, COUNT(*) AS count").
+		Where("link_id = ?", linkID)
+	err := filter.apply(query).
+		Group(column).
+		Order("count DESC, value").
+		Limit(limit).
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ForEachVisitorHash parcourt, par ordre chronologique, les empreintes de visiteurs des clics
+// d'un lien compris dans l'intervalle [from, to) et appelle fn pour chacune.
+// Les lignes sont lues au fil de l'eau pour ne pas charger toute la plage en mémoire ;
+// le parcours s'arrête à la première erreur retournée par fn.
+func (r *GormClickRepository) ForEachVisitorHash(linkID uint, from, to time.Time, filter ClickFilter, fn func(timestamp time.Time, visitorHash string) error) error {
+	query := r.db.Model(&models.Click{}).
+		Select("timestamp, visitor_hash").
+		Where("link_id = ? AND timestamp >= ? AND timestamp < ?", linkID, from.Local(), to.Local()).
+		Where("visitor_hash <> ''")
+	rows, err := filter.apply(query).Order("timestamp").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var timestamp time.Time
+		var visitorHash string
+		if err := rows.Scan(&timestamp, &visitorHash); err != nil {
+			return err
+		}
+		if err := fn(timestamp, visitorHash); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// CountClicksBefore compte les clics détaillés enregistrés avant 'cutoff'.
+func (r *GormClickRepository) CountClicksBefore(cutoff time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Click{}).Where("timestamp < ?", cutoff.Local()).Count(&count).Error
+	return count, err
+}
+
+// DeleteClicksBefore supprime définitivement les clics enregistrés avant 'cutoff'
+// et retourne le nombre de lignes supprimées.
+func (r *GormClickRepository) DeleteClicksBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("timestamp < ?", cutoff.Local()).Delete(&models.Click{})
+	return result.RowsAffected, result.Error
+}
+
+// AggregateClicksBefore remplace les clics enregistrés avant 'cutoff' par des compteurs
+// journaliers par lien (table 'click_aggregates'), puis supprime les clics détaillés.
+// L'opération est atomique et retourne le nombre de clics agrégés.
+func (r *GormClickRepository) AggregateClicksBefore(cutoff time.Time) (int64, error) {
+	type aggregateKey struct {
+		linkID uint
+		day    time.Time
+	}
+
+	var total int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Le regroupement par jour est fait côté Go pour rester indépendant du moteur SQL.
+		rows, err := tx.Model(&models.Click{}).
+			Select("link_id, timestamp, is_bot, fallback").
+			Where("timestamp < ?", cutoff.Local()).
+			Rows()
+		if err != nil {
+			return err
+		}
+
+		counts := make(map[aggregateKey]*models.ClickAggregate)
+		for rows.Next() {
+			var linkID uint
+			var timestamp time.Time
+			var isBot, fallback bool
+			if err := rows.Scan(&linkID, &timestamp, &isBot, &fallback); err != nil {
+				rows.Close()
+				return err
+			}
+
+			utc := timestamp.UTC()
+			key := aggregateKey{linkID: linkID, day: time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)}
+			agg, ok := counts[key]
+			if !ok {
+				agg = &models.ClickAggregate{LinkID: key.linkID, Day: key.day}
+				counts[key] = agg
+			}
+			if isBot {
+				agg.BotClicks++
+			} else {
+				agg.Clicks++
+			}
+			if fallback {
+				agg.FallbackClicks++
+			}
+			total++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// Un agrégat existant pour le même lien et le même jour est incrémenté.
+		for _, agg := range counts {
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "link_id"}, {Name: "day"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"clicks":          gorm.Expr("click_aggregates.clicks + ?", agg.Clicks),
+					"bot_clicks":      gorm.Expr("click_aggregates.bot_clicks + ?", agg.BotClicks),
+					"fallback_clicks": gorm.Expr("click_aggregates.fallback_clicks + ?", agg.FallbackClicks),
+				}),
+			}).Create(agg).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Where("timestamp < ?", cutoff.Local()).Delete(&models.Click{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
 }
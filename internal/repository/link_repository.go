@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
 	"urlshortener/internal/models"
 
 	"gorm.io/gorm"
@@ -12,9 +15,28 @@ type LinkRepository interface {
 	CreateLink(link *models.Link) error
 	GetLinkByShortCode(shortCode string) (*models.Link, error)
 	GetAllLinks() ([]models.Link, error)
-	CountClicksByLinkID(linkID uint) (int, error)
+	ListLinks(filter LinkFilter) ([]models.Link, int64, error)
+	UpdateLink(link *models.Link) error
+	DeleteLink(link *models.Link) error
+	RestoreLink(shortCode string, ownerKeyID *uint) (*models.Link, error)
+	CountClicksByLinkID(linkID uint, filter ClickFilter) (int, error)
+	ReserveClick(link *models.Link) (bool, error)
+}
+
+// LinkFilter regroupe les critères de recherche et de pagination utilisés par ListLinks.
+// Les champs laissés à leur valeur zéro ne filtrent pas.
+type LinkFilter struct {
+	CreatedAfter  *time.Time // Liens créés à partir de cette date (incluse)
+	CreatedBefore *time.Time // Liens créés avant cette date (exclue)
+	URLContains   string     // Sous-chaîne recherchée dans l'URL longue
+	OwnerKeyID    *uint      // Liens créés par cette clé API uniquement
+	Offset        int        // Nombre de liens à ignorer
+	Limit         int        // Nombre maximum de liens retournés (0 = pas de limite)
 }
 
+// likeEscaper neutralise les caractères spéciaux d'un motif LIKE (%, _ et \), avec \ pour caractère d'échappement.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 type GormLinkRepository struct {
 	db *gorm.DB
 }
@@ -55,13 +77,100 @@ func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	return links, nil
 }
 
-// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
-func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
-	var count int64
-	err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error
+// ListLinks retourne les liens correspondant au filtre, du plus récent au plus ancien,
+// ainsi que le nombre total de liens correspondants (avant pagination).
+// Les liens supprimés (soft delete) sont exclus.
+func (r *GormLinkRepository) ListLinks(filter LinkFilter) ([]models.Link, int64, error) {
+	// Les dates de création sont enregistrées dans le fuseau local du serveur : on compare dans ce même fuseau.
+	query := r.db.Model(&models.Link{})
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", filter.CreatedAfter.Local())
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", filter.CreatedBefore.Local())
+	}
+	if filter.URLContains != "" {
+		// Le caractère d'échappement est passé en paramètre : le littéral '\' n'est pas valide avec MySQL,
+		// où \ échappe aussi les chaînes.
+		query = query.Where("long_url LIKE ? ESCAPE ?", "%"+likeEscaper.Replace(filter.URLContains)+"%", `\`)
+	}
+	if filter.OwnerKeyID != nil {
+		query = query.Where("api_key_id = ?", *filter.OwnerKeyID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var links []models.Link
+	if err := query.Order("created_at DESC, id DESC").Find(&links).Error; err != nil {
+		return nil, 0, err
+	}
+	return links, total, nil
+}
+
+// UpdateLink enregistre toutes les modifications apportées à un lien existant.
+func (r *GormLinkRepository) UpdateLink(link *models.Link) error {
+	// clicks_used n'est modifié que par ReserveClick : la valeur lue avec le lien peut être périmée.
+	return r.db.Omit("ClicksUsed").Save(link).Error
+}
+
+// DeleteLink supprime un lien de manière logique (soft delete) : la ligne est conservée
+// avec un horodatage deleted_at et peut être restaurée avec RestoreLink.
+func (r *GormLinkRepository) DeleteLink(link *models.Link) error {
+	return r.db.Delete(link).Error
+}
+
+// RestoreLink annule la suppression logique d'un lien identifié par son shortCode.
+// Si ownerKeyID est renseigné, seul un lien créé par cette clé API peut être restauré.
+// Il renvoie gorm.ErrRecordNotFound si aucun lien supprimé ne correspond.
+func (r *GormLinkRepository) RestoreLink(shortCode string, ownerKeyID *uint) (*models.Link, error) {
+	query := r.db.Unscoped().Where("short_code = ? AND deleted_at IS NOT NULL", shortCode)
+	if ownerKeyID != nil {
+		query = query.Where("api_key_id = ?", *ownerKeyID)
+	}
+
+	var link models.Link
+	err := query.First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Unscoped().Model(&link).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	link.DeletedAt = gorm.DeletedAt{}
+	return &link, nil
+}
+
+// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné,
+// y compris les clics agrégés par la politique de rétention.
+func (r *GormLinkRepository) CountClicksByLinkID(linkID uint, filter ClickFilter) (int, error) {
+	count, err := countClicks(r.db, linkID, filter)
 
 	if err != nil {
 		return 0, err
 	}
 	return int(count), nil
 }
+
+// ReserveClick incrémente clicks_used d'un lien doté d'un budget si celui-ci n'est pas épuisé.
+// La vérification et l'incrément forment une seule requête UPDATE, atomique même entre plusieurs
+// instances du serveur. Il retourne false si le budget est épuisé ou si le lien n'existe pas.
+func (r *GormLinkRepository) ReserveClick(link *models.Link) (bool, error) {
+	result := r.db.Model(&models.Link{}).
+		Where("id = ? AND max_clicks IS NOT NULL AND clicks_used < max_clicks", link.ID).
+		UpdateColumn("clicks_used", gorm.Expr("clicks_used + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+
+	"urlshortener/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestLinkRepository crée un GormLinkRepository sur une base SQLite en mémoire.
+func newTestLinkRepository(t *testing.T) *GormLinkRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.Link{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	return NewLinkRepository(db)
+}
+
+func TestListLinksURLContainsIsLiteral(t *testing.T) {
+	repo := newTestLinkRepository(t)
+	for i, url := range []string{
+		"https://example.com/100%25-off",
+		"https://example.com/a_b",
+		"https://example.com/aXb",
+		`https://example.com/a\b`,
+		`https://example.com/a\\b`,
+	} {
+		link := &models.Link{ShortCode: string(rune('a' + i)), LongURL: url}
+		if err := repo.CreateLink(link); err != nil {
+			t.Fatalf("CreateLink: %v", err)
+		}
+	}
+
+	tests := []struct {
+		contains string
+		want     int
+	}{
+		{"%", 1},
+		{"a_b", 1},
+		{"_", 1},
+		{`a\b`, 1},
+		{`\`, 2},
+		{"example", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contains, func(t *testing.T) {
+			_, total, err := repo.ListLinks(LinkFilter{URLContains: tt.contains})
+			if err != nil {
+				t.Fatalf("ListLinks: %v", err)
+			}
+			if total != int64(tt.want) {
+				t.Fatalf("%d link(s) contain %q, want %d", total, tt.contains, tt.want)
+			}
+		})
+	}
+}
+
+func TestReserveClick(t *testing.T) {
+	maxClicks := 3
+	tests := []struct {
+		name      string
+		maxClicks *int
+		want      int // Réservations acceptées parmi 10 concurrentes
+	}{
+		{"budget", &maxClicks, 3},
+		{"no budget", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestLinkRepository(t)
+			link := &models.Link{ShortCode: "abc", LongURL: "https://example.com", MaxClicks: tt.maxClicks}
+			if err := repo.CreateLink(link); err != nil {
+				t.Fatalf("CreateLink: %v", err)
+			}
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			accepted := 0
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ok, err := repo.ReserveClick(link)
+					if err != nil {
+						t.Errorf("ReserveClick: %v", err)
+					}
+					if ok {
+						mu.Lock()
+						accepted++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			if accepted != tt.want {
+				t.Fatalf("%d reservation(s) accepted, want %d", accepted, tt.want)
+			}
+
+			stored, err := repo.GetLinkByShortCode("abc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.ClicksUsed != tt.want {
+				t.Fatalf("clicks_used = %d, want %d", stored.ClicksUsed, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateLinkKeepsClicksUsed(t *testing.T) {
+	repo := newTestLinkRepository(t)
+	maxClicks := 5
+	link := &models.Link{ShortCode: "abc", LongURL: "https://example.com", MaxClicks: &maxClicks}
+	if err := repo.CreateLink(link); err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+
+	// Le lien modifié a été lu avant une redirection : sa valeur de ClicksUsed est périmée.
+	stale, err := repo.GetLinkByShortCode("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := repo.ReserveClick(link); err != nil || !ok {
+		t.Fatalf("ReserveClick = %v, %v, want true", ok, err)
+	}
+	stale.LongURL = "https://example.com/updated"
+	if err := repo.UpdateLink(stale); err != nil {
+		t.Fatalf("UpdateLink: %v", err)
+	}
+
+	stored, err := repo.GetLinkByShortCode("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.LongURL != "https://example.com/updated" || stored.ClicksUsed != 1 {
+		t.Fatalf("stored link = %q with clicks_used %d, want the updated URL with clicks_used 1", stored.LongURL, stored.ClicksUsed)
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"urlshortener/internal/models"
+	"urlshortener/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Permissions attribuables à une clé API.
+const (
+	ScopeLinksRead  = "links:read"  // Lister et consulter ses liens
+	ScopeLinksWrite = "links:write" // Créer, modifier, supprimer et restaurer ses liens
+	ScopeStatsRead  = "stats:read"  // Consulter les statistiques de ses liens
+)
+
+// AllScopes liste les permissions connues, attribuées par défaut à une nouvelle clé.
+var AllScopes = []string{ScopeLinksRead, ScopeLinksWrite, ScopeStatsRead}
+
+// Format des clés API : apiKeyPrefix suivi de apiKeyRandomBytes octets aléatoires encodés en base64url.
+// Les apiKeyDisplayLength premiers caractères sont conservés en clair pour identifier la clé.
+const (
+	apiKeyPrefix        = "usk_"
+	apiKeyRandomBytes   = 32
+	apiKeyDisplayLength = 12
+)
+
+// apiKeyTouchInterval limite la fréquence d'écriture de la date de dernière utilisation.
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyService gère la création, l'authentification et la révocation des clés API.
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+}
+
+// NewAPIKeyService crée et retourne une nouvelle instance de APIKeyService.
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+// ParseScopes valide une liste de permissions séparées par des virgules ou des espaces.
+// Une liste vide correspond à toutes les permissions.
+func ParseScopes(value string) ([]string, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) == 0 {
+		return slices.Clone(AllScopes), nil
+	}
+
+	var scopes []string
+	for _, scope := range fields {
+		if !slices.Contains(AllScopes, scope) {
+			return nil, fmt.Errorf("%w: %q (expected %s)", ErrInvalidScope, scope, strings.Join(AllScopes, ", "))
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// HasScope indique si une clé possède une permission.
+func HasScope(key *models.APIKey, scope string) bool {
+	return slices.Contains(strings.Fields(key.Scopes), scope)
+}
+
+// hashAPIKey retourne l'empreinte stockée d'une clé. Les clés étant aléatoires et longues,
+// un hachage rapide suffit : il n'y a pas de dictionnaire à craindre.
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateKey génère une nouvelle clé API et l'enregistre. La clé en clair est retournée
+// une seule fois et n'est pas conservée.
+func (s *APIKeyService) CreateKey(name string, scopes []string) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("api key name is required")
+	}
+
+	random := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", fmt.Errorf("error generating api key: %w", err)
+	}
+	rawKey := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	key := &models.APIKey{
+		Name:   name,
+		Prefix: rawKey[:apiKeyDisplayLength],
+		Hash:   hashAPIKey(rawKey),
+		Scopes: strings.Join(scopes, " "),
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(key); err != nil {
+		return nil, "", fmt.Errorf("error creating api key: %w", err)
+	}
+	return key, rawKey, nil
+}
+
+// Authenticate retrouve la clé active correspondant à une valeur fournie par un client.
+// Il retourne ErrInvalidAPIKey si la clé est inconnue ou révoquée.
+func (s *APIKeyService) Authenticate(rawKey string) (*models.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetAPIKeyByHash(hashAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("error retrieving api key: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchAPIKey(key.ID, now); err != nil {
+			slog.Error("Erreur lors de la mise à jour de la dernière utilisation de la clé API", "api_key_id", key.ID, "error", err)
+		}
+	}
+	return key, nil
+}
+
+// ListKeys retourne toutes les clés API, révoquées comprises.
+func (s *APIKeyService) ListKeys() ([]models.APIKey, error) {
+	keys, err := s.apiKeyRepo.ListAPIKeys()
+	if err != nil {
+		return nil, fmt.Errorf("error listing api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey révoque une clé : elle ne permet plus d'accéder à l'API, mais ses liens sont conservés.
+func (s *APIKeyService) RevokeKey(id uint) error {
+	if err := s.apiKeyRepo.RevokeAPIKey(id, time.Now()); err != nil {
+		return fmt.Errorf("error revoking api key: %w", err)
+	}
+	return nil
+}
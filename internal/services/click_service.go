@@ -1,10 +1,100 @@
 package services
 
 import (
-	"urlshortener/internal/models"
+	"fmt"
+	"time"
+	_ "time/tzdata" // Embarque la base des fuseaux horaires pour le paramètre timezone
+
+	"urlshortener/internal/hll"
 	"urlshortener/internal/repository" // Importe le package repository
 )
 
+// Interval est la granularité d'une série temporelle de clics.
+type Interval string
+
+// Granularités supportées pour les séries temporelles.
+const (
+	IntervalHour Interval = "hour"
+	IntervalDay  Interval = "day"
+	IntervalWeek Interval = "week"
+)
+
+// maxTimeSeriesBuckets limite la taille d'une série pour éviter les réponses démesurées
+// (ex: un an en granularité horaire).
+const maxTimeSeriesBuckets = 2000
+
+// TimeBucket représente le nombre de clics sur une période commençant à Start.
+type TimeBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// ParseInterval convertit une chaîne en Interval, en retournant ErrInvalidInterval si elle n'est pas supportée.
+func ParseInterval(value string) (Interval, error) {
+	switch Interval(value) {
+	case IntervalHour, IntervalDay, IntervalWeek:
+		return Interval(value), nil
+	}
+	return "", fmt.Errorf("%w: %q (expected hour, day or week)", ErrInvalidInterval, value)
+}
+
+// ParseDate convertit une date au format RFC 3339 ou AAAA-MM-JJ, utilisée par les filtres de l'API
+// et de la CLI. Une date sans heure est interprétée à minuit dans le fuseau loc.
+// Elle retourne nil si value est vide, et ErrInvalidDate si le format n'est pas reconnu.
+func ParseDate(value string, loc *time.Location) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q (expected RFC 3339 or YYYY-MM-DD)", ErrInvalidDate, value)
+	}
+	return &t, nil
+}
+
+// truncate ramène t au début de la période qui le contient, dans le fuseau loc.
+// Les semaines commencent le lundi.
+func (i Interval) truncate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch i {
+	case IntervalHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	case IntervalWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+// DefaultSpan retourne la durée couverte par défaut par une série de cette granularité
+// lorsque le début de la plage n'est pas précisé.
+func (i Interval) DefaultSpan() time.Duration {
+	switch i {
+	case IntervalHour:
+		return 24 * time.Hour
+	case IntervalWeek:
+		return 12 * 7 * 24 * time.Hour
+	default:
+		return 30 * 24 * time.Hour
+	}
+}
+
+// next retourne le début de la période suivant celle commençant à start.
+func (i Interval) next(start time.Time) time.Time {
+	switch i {
+	case IntervalHour:
+		return start.Add(time.Hour)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
 // TODO : créer la struct
 // ClickService est une structure qui fournit des méthodes pour la logique métier des clics.
 // Elle est juste composer de clickRepo qui est de type ClickRepository
@@ -21,24 +111,168 @@ func NewClickService(clickRepo repository.ClickRepository) *ClickService {
 	}
 }
 
-// RecordClick enregistre un nouvel événement de clic dans la base de données.
-// Cette méthode est appelée par le worker asynchrone.
-func (s *ClickService) RecordClick(click *models.Click) error {
-	// TODO 1: Appeler le ClickRepository (CreateClick) pour créer l'enregistrement de clic.
-	// Gérer toute erreur provenant du repository.
-	newClick := &models.Click{
-		LinkID:    click.LinkID,
-		Timestamp: click.Timestamp,
-		UserAgent: click.UserAgent,
-		IPAddress: click.IPAddress,
-	}
-	
-	return s.clickRepo.CreateClick(newClick)
-}
-
 // GetClicksCountByLinkID récupère le nombre total de clics pour un LinkID donné.
 // Cette méthode pourrait être utilisée par le LinkService pour les statistiques, ou directement par l'API stats.
-func (s *ClickService) GetClicksCountByLinkID(linkID uint) (int, error) {
+func (s *ClickService) GetClicksCountByLinkID(linkID uint, filter repository.ClickFilter) (int, error) {
 	// TODO 2: Appeler le ClickRepository (CountclicksByLinkID) pour compter les clics par LinkID.
-	return s.clickRepo.CountClicksByLinkID(linkID)
+	return s.clickRepo.CountClicksByLinkID(linkID, filter)
+}
+
+// GetFallbackRedirects retourne le nombre de redirections d'un lien servies vers une URL de repli, robots compris.
+func (s *ClickService) GetFallbackRedirects(linkID uint) (int64, error) {
+	return s.clickRepo.CountFallbackClicks(linkID)
+}
+
+// GetClickTimeSeries retourne le nombre de clics d'un lien par période sur l'intervalle [from, to),
+// les périodes étant calculées dans le fuseau loc. Les périodes sans clic sont présentes avec un
+// compteur à zéro afin que la série soit continue.
+func (s *ClickService) GetClickTimeSeries(linkID uint, from, to time.Time, interval Interval, loc *time.Location, filter repository.ClickFilter) ([]TimeBucket, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: 'from' must be before 'to'", ErrInvalidTimeRange)
+	}
+
+	// Construction des périodes vides couvrant tout l'intervalle.
+	var buckets []TimeBucket
+	for start := interval.truncate(from, loc); start.Before(to); start = interval.next(start) {
+		if len(buckets) == maxTimeSeriesBuckets {
+			return nil, fmt.Errorf("%w: too many buckets (max %d), use a larger interval", ErrInvalidTimeRange, maxTimeSeriesBuckets)
+		}
+		buckets = append(buckets, TimeBucket{Start: start})
+	}
+
+	timestamps, err := s.clickRepo.GetClickTimestamps(linkID, from, to, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving clicks: %w", err)
+	}
+
+	// Les horodatages sont triés : on avance dans les périodes au fil des clics.
+	idx := 0
+	for _, ts := range timestamps {
+		for idx < len(buckets)-1 && !ts.Before(buckets[idx+1].Start) {
+			idx++
+		}
+		buckets[idx].Count++
+	}
+
+	return buckets, nil
+}
+
+// breakdownDimensions associe le nom public de chaque répartition à son axe de regroupement.
+var breakdownDimensions = []struct {
+	name      string
+	dimension repository.ClickDimension
+	emptyAs   string // Libellé affiché pour une valeur vide
+}{
+	{"referrers", repository.DimensionReferrer, "(direct)"},
+	{"browsers", repository.DimensionBrowser, "(unknown)"},
+	{"os", repository.DimensionOS, "(unknown)"},
+	{"devices", repository.DimensionDevice, "(unknown)"},
+}
+
+// GetClickBreakdowns retourne, pour un lien, les 'top' valeurs les plus fréquentes
+// des referrers, navigateurs, systèmes d'exploitation et classes d'appareils.
+func (s *ClickService) GetClickBreakdowns(linkID uint, top int, filter repository.ClickFilter) (map[string][]repository.BreakdownEntry, error) {
+	breakdowns := make(map[string][]repository.BreakdownEntry, len(breakdownDimensions))
+	for _, d := range breakdownDimensions {
+		entries, err := s.clickRepo.GetClickBreakdown(linkID, d.dimension, top, filter)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving %s breakdown: %w", d.name, err)
+		}
+		for i := range entries {
+			if entries[i].Value == "" {
+				entries[i].Value = d.emptyAs
+			}
+		}
+		if entries == nil {
+			entries = []repository.BreakdownEntry{}
+		}
+		breakdowns[d.name] = entries
+	}
+	return breakdowns, nil
+}
+
+// exactUniqueVisitorsMaxDays est la longueur de plage au-delà de laquelle le total de visiteurs
+// uniques est estimé avec un HyperLogLog plutôt que compté exactement, afin de borner la mémoire.
+const exactUniqueVisitorsMaxDays = 31
+
+// DailyCount représente un nombre de visiteurs uniques pour une journée.
+type DailyCount struct {
+	Date  string `json:"date"` // Jour au format AAAA-MM-JJ (UTC pour les visiteurs uniques)
+	Count int    `json:"count"`
+}
+
+// UniqueVisitors regroupe les visiteurs uniques d'un lien sur une plage de dates.
+// Les empreintes de visiteurs étant renouvelées chaque jour, le total de la plage compte
+// les couples (visiteur, jour) distincts : un visiteur revenu deux jours différents compte deux fois.
+type UniqueVisitors struct {
+	Total       int          `json:"total"`
+	Approximate bool         `json:"approximate"` // Vrai si le total est une estimation (HyperLogLog)
+	Daily       []DailyCount `json:"daily"`
+}
+
+// GetUniqueVisitors compte les visiteurs uniques d'un lien par jour et sur l'ensemble de l'intervalle
+// [from, to). Les jours sont des jours UTC quel que soit le fuseau de la requête : c'est l'unité de
+// renouvellement des empreintes (voir privacy.VisitorHasher), un jour local couvrirait deux empreintes
+// par visiteur. Les comptes journaliers sont exacts ; le total est estimé par un HyperLogLog lorsque
+// la plage dépasse exactUniqueVisitorsMaxDays jours.
+func (s *ClickService) GetUniqueVisitors(linkID uint, from, to time.Time, filter repository.ClickFilter) (*UniqueVisitors, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: 'from' must be before 'to'", ErrInvalidTimeRange)
+	}
+
+	var days []time.Time
+	for start := IntervalDay.truncate(from, time.UTC); start.Before(to); start = IntervalDay.next(start) {
+		if len(days) == maxTimeSeriesBuckets {
+			return nil, fmt.Errorf("%w: too many days (max %d)", ErrInvalidTimeRange, maxTimeSeriesBuckets)
+		}
+		days = append(days, start)
+	}
+
+	result := &UniqueVisitors{
+		Approximate: len(days) > exactUniqueVisitorsMaxDays,
+		Daily:       make([]DailyCount, len(days)),
+	}
+	for i, day := range days {
+		result.Daily[i].Date = day.Format(time.DateOnly)
+	}
+
+	// Le total est compté dans un ensemble exact ou dans un HyperLogLog selon la longueur de la plage ;
+	// les ensembles journaliers sont réinitialisés à chaque changement de jour.
+	var exactTotal map[string]struct{}
+	var sketch *hll.Sketch
+	if result.Approximate {
+		sketch = hll.New()
+	} else {
+		exactTotal = make(map[string]struct{})
+	}
+
+	idx := 0
+	daySeen := make(map[string]struct{})
+	err := s.clickRepo.ForEachVisitorHash(linkID, from, to, filter, func(ts time.Time, visitorHash string) error {
+		for idx < len(days)-1 && !ts.Before(days[idx+1]) {
+			idx++
+			daySeen = make(map[string]struct{})
+		}
+		if _, seen := daySeen[visitorHash]; !seen {
+			daySeen[visitorHash] = struct{}{}
+			result.Daily[idx].Count++
+		}
+
+		if sketch != nil {
+			sketch.Add(visitorHash)
+		} else {
+			exactTotal[visitorHash] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving visitors: %w", err)
+	}
+
+	if sketch != nil {
+		result.Total = sketch.Count()
+	} else {
+		result.Total = len(exactTotal)
+	}
+	return result, nil
 }
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Erreurs métier personnalisées retournées par les services.
+// Les handlers de l'API les traduisent en codes HTTP via errors.Is.
+var (
+	// ErrInvalidAlias est retournée quand un alias personnalisé ne respecte pas le format attendu.
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrReservedAlias est retournée quand un alias entre en conflit avec une route du service.
+	ErrReservedAlias = errors.New("alias is reserved")
+	// ErrAliasTaken est retournée quand un alias personnalisé est déjà utilisé par un autre lien.
+	ErrAliasTaken = errors.New("alias already taken")
+	// ErrInvalidExpiration est retournée quand la date d'expiration ou le budget de clics est incohérent.
+	ErrInvalidExpiration = errors.New("invalid expiration")
+	// ErrLinkExpired est retournée quand un lien a dépassé sa date d'expiration ou son budget de clics.
+	ErrLinkExpired = errors.New("link expired")
+	// ErrInvalidInterval est retournée quand la granularité d'une série temporelle n'est pas supportée.
+	ErrInvalidInterval = errors.New("invalid interval")
+	// ErrInvalidTimeRange est retournée quand la plage from/to d'une série temporelle est incohérente.
+	ErrInvalidTimeRange = errors.New("invalid time range")
+	// ErrInvalidDate est retournée quand une date n'est ni au format RFC 3339 ni au format AAAA-MM-JJ.
+	ErrInvalidDate = errors.New("invalid date")
+	// ErrInvalidRetentionPolicy est retournée quand la politique de rétention des clics est invalide ou désactivée.
+	ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+	// ErrInvalidTag est retournée quand une étiquette de lien ne respecte pas le format attendu.
+	ErrInvalidTag = errors.New("invalid tag")
+	// ErrInvalidAPIKey est retournée quand une clé API est inconnue ou révoquée.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrInvalidScope est retournée quand une permission de clé API n'existe pas.
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// ErrURLRejected est retournée (via URLRejectedError) quand une URL de destination est refusée par une URLPolicy.
+var ErrURLRejected = errors.New("url rejected")
+
+// Codes de refus d'une URL de destination, renvoyés au client avec le code HTTP 422.
+const (
+	ReasonInvalidURL       = "invalid_url"
+	ReasonSchemeNotAllowed = "scheme_not_allowed"
+	ReasonDomainBlocked    = "domain_blocked"
+	ReasonPrivateAddress   = "private_address"
+	ReasonUnresolvableHost = "unresolvable_host"
+)
+
+// URLRejectedError détaille le refus d'une URL de destination par une URLPolicy.
+// Elle satisfait errors.Is(err, ErrURLRejected).
+type URLRejectedError struct {
+	Reason string // Code de refus (Reason*)
+	Detail string // Explication lisible
+}
+
+func (e *URLRejectedError) Error() string {
+	return fmt.Sprintf("%v (%s): %s", ErrURLRejected, e.Reason, e.Detail)
+}
+
+func (e *URLRejectedError) Unwrap() error {
+	return ErrURLRejected
+}
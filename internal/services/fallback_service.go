@@ -0,0 +1,48 @@
+package services
+
+import "urlshortener/internal/models"
+
+// DestinationStatus fournit l'état de l'URL longue des liens constaté par le moniteur.
+type DestinationStatus interface {
+	// ConsecutiveFailures retourne le nombre de vérifications consécutives en échec de l'URL longue du lien.
+	ConsecutiveFailures(link *models.Link) int
+}
+
+// FallbackService choisit la destination des redirections : l'URL longue du lien, ou son URL de repli
+// (à défaut l'URL de repli globale) lorsque l'URL longue a échoué à 'threshold' vérifications consécutives.
+// Les redirections reviennent à l'URL longue dès sa première vérification réussie.
+type FallbackService struct {
+	status     DestinationStatus
+	defaultURL string
+	threshold  int
+}
+
+// NewFallbackService crée et retourne une nouvelle instance de FallbackService.
+// defaultURL est utilisée pour les liens sans URL de repli (vide = pas de repli pour ces liens).
+// Un seuil inférieur à 1 est ramené à 1.
+func NewFallbackService(status DestinationStatus, defaultURL string, threshold int) *FallbackService {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &FallbackService{
+		status:     status,
+		defaultURL: defaultURL,
+		threshold:  threshold,
+	}
+}
+
+// RedirectTarget retourne l'URL vers laquelle rediriger un visiteur du lien et indique s'il s'agit
+// d'une URL de repli. Un service nil redirige toujours vers l'URL longue.
+func (s *FallbackService) RedirectTarget(link *models.Link) (string, bool) {
+	if s == nil {
+		return link.LongURL, false
+	}
+	fallbackURL := link.FallbackURL
+	if fallbackURL == "" {
+		fallbackURL = s.defaultURL
+	}
+	if fallbackURL == "" || s.status.ConsecutiveFailures(link) < s.threshold {
+		return link.LongURL, false
+	}
+	return fallbackURL, true
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"urlshortener/internal/models"
+	"urlshortener/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Statuts de santé d'un lien.
+const (
+	HealthUp             = models.CheckStateUp
+	HealthDown           = models.CheckStateDown
+	HealthContentChanged = models.CheckStateContentChanged // Accessible, contenu modifié lors de la dernière vérification
+	HealthUnknown        = "unknown"                       // Jamais vérifié
+)
+
+// UptimeWindow est la disponibilité d'un lien sur une fenêtre glissante :
+// la part des vérifications où l'URL était accessible.
+type UptimeWindow struct {
+	Percent *float64 `json:"percent"` // nil si aucune vérification sur la fenêtre
+	Checks  int64    `json:"checks"`
+}
+
+// LinkHealth est l'état de santé d'un lien déduit de l'historique des vérifications.
+type LinkHealth struct {
+	Status        string     `json:"status"`
+	StatusCode    int        `json:"status_code,omitempty"`
+	ErrorClass    string     `json:"error_class,omitempty"`
+	LatencyMs     int        `json:"latency_ms,omitempty"`
+	FinalURL      string     `json:"final_url,omitempty"` // URL atteinte après les redirections
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	LastChangeAt  *time.Time `json:"last_change_at"` // Début de l'état actuel
+	Uptime        struct {
+		Day   UptimeWindow `json:"24h"`
+		Week  UptimeWindow `json:"7d"`
+		Month UptimeWindow `json:"30d"`
+	} `json:"uptime"`
+}
+
+// HealthService fournit l'état de santé des liens à partir des vérifications du moniteur.
+type HealthService struct {
+	checkRepo repository.CheckRepository
+}
+
+// NewHealthService crée et retourne une nouvelle instance de HealthService.
+func NewHealthService(checkRepo repository.CheckRepository) *HealthService {
+	return &HealthService{checkRepo: checkRepo}
+}
+
+// GetLinkHealth retourne le statut actuel d'un lien, la date de son dernier changement d'état
+// et sa disponibilité sur 24 heures, 7 jours et 30 jours.
+func (s *HealthService) GetLinkHealth(link *models.Link) (*LinkHealth, error) {
+	health := &LinkHealth{Status: HealthUnknown}
+
+	latest, err := s.checkRepo.GetLatestCheck(link.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return health, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving latest check: %w", err)
+	}
+
+	health.Status = latest.CheckState()
+	health.StatusCode = latest.StatusCode
+	health.ErrorClass = latest.ErrorClass
+	health.LatencyMs = latest.LatencyMs
+	health.FinalURL = latest.FinalURL
+	health.LastCheckedAt = &latest.CheckedAt
+
+	if health.Status == HealthContentChanged {
+		// Le changement de contenu date de la dernière vérification.
+		health.LastChangeAt = &latest.CheckedAt
+	} else if health.LastChangeAt, err = s.checkRepo.GetLastStateChange(link.ID, latest.Up); err != nil {
+		return nil, fmt.Errorf("error retrieving last state change: %w", err)
+	}
+
+	now := time.Now()
+	windows := []struct {
+		span   time.Duration
+		window *UptimeWindow
+	}{
+		{24 * time.Hour, &health.Uptime.Day},
+		{7 * 24 * time.Hour, &health.Uptime.Week},
+		{30 * 24 * time.Hour, &health.Uptime.Month},
+	}
+	for _, w := range windows {
+		total, up, err := s.checkRepo.CountChecksSince(link.ID, now.Add(-w.span))
+		if err != nil {
+			return nil, fmt.Errorf("error computing uptime: %w", err)
+		}
+		w.window.Checks = total
+		if total > 0 {
+			percent := float64(up) * 100 / float64(total)
+			w.window.Percent = &percent
+		}
+	}
+	return health, nil
+}
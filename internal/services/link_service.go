@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"gorm.io/gorm" // Nécessaire pour la gestion spécifique de gorm.ErrRecordNotFound
-	"log"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
 
 	"urlshortener/internal/models"
 	"urlshortener/internal/repository" // Importe le package repository
@@ -14,20 +17,70 @@ import (
 // Définition du jeu de caractères pour la génération des codes courts.
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
+// Bornes de longueur d'un alias personnalisé. La borne haute correspond à la taille
+// de la colonne short_code (voir models.Link).
+const (
+	minAliasLength = 3
+	maxAliasLength = 32
+)
+
+// Bornes des étiquettes d'un lien. La longueur totale, séparateurs compris, correspond à la taille
+// de la colonne tags (voir models.Link).
+const (
+	maxTagLength     = 32
+	maxTags          = 10
+	maxTagsTotalSize = 255
+)
+
+// tagPattern définit les caractères autorisés dans une étiquette, après conversion en minuscules.
+var tagPattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// aliasPattern définit les caractères autorisés dans un alias personnalisé.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// reservedAliases liste les alias qui entreraient en conflit avec les routes du service.
+// La comparaison se fait sans tenir compte de la casse.
+var reservedAliases = map[string]bool{
+	"health":  true,
+	"api":     true,
+	"metrics": true,
+	"admin":   true,
+	"static":  true,
+}
+
 // TODO Créer la struct
 // LinkService est une structure qui g fournit des méthodes pour la logique métier des liens.
 // Elle détient linkRepo qui est une référence vers une interface LinkRepository.
 // IMPORTANT : Le champ doit être du type de l'interface (non-pointeur).
 
 type LinkService struct {
-	linkRepo repository.LinkRepository
+	linkRepo    repository.LinkRepository
+	urlPolicies []URLPolicy // Contrôles appliqués aux URLs de destination, dans l'ordre
+}
+
+// URLPolicy contrôle une URL de destination avant qu'elle soit associée à un lien.
+// CheckURL retourne un *URLRejectedError si l'URL est refusée.
+type URLPolicy interface {
+	CheckURL(longURL string) error
 }
 
 // NewLinkService crée et retourne une nouvelle instance de LinkService.
-func NewLinkService(linkRepo repository.LinkRepository) *LinkService {
+// Les URLs de destination des liens créés ou modifiés doivent satisfaire toutes les urlPolicies.
+func NewLinkService(linkRepo repository.LinkRepository, urlPolicies ...URLPolicy) *LinkService {
 	return &LinkService{
-		linkRepo: linkRepo,
+		linkRepo:    linkRepo,
+		urlPolicies: urlPolicies,
+	}
+}
+
+// checkURL applique les URLPolicy du service à une URL de destination.
+func (s *LinkService) checkURL(longURL string) error {
+	for _, policy := range s.urlPolicies {
+		if err := policy.CheckURL(longURL); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // GenerateShortCode est une méthode rattachée à LinkService
@@ -50,50 +103,167 @@ func (s *LinkService) GenerateShortCode() (string, error) {
 	return string(shortCode), nil
 }
 
+// LinkOptions regroupe les paramètres optionnels de création d'un lien.
+type LinkOptions struct {
+	Alias     string     // Alias personnalisé utilisé à la place d'un code généré (optionnel)
+	ExpiresAt *time.Time // Date après laquelle le lien n'est plus redirigé (optionnel)
+	MaxClicks *int       // Nombre maximum de redirections autorisées (optionnel)
+	APIKeyID  *uint      // Clé API à l'origine de la création, propriétaire du lien (optionnel)
+	Tags      []string   // Étiquettes du lien, utilisées pour router les notifications (optionnel)
+
+	FallbackURL string // URL servie quand l'URL longue est inaccessible (optionnel, voir FallbackService)
+}
+
+// validateLifetime vérifie la cohérence des options d'expiration d'un lien.
+func validateLifetime(opts LinkOptions) error {
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: expiration date must be in the future", ErrInvalidExpiration)
+	}
+	if opts.MaxClicks != nil && *opts.MaxClicks < 1 {
+		return fmt.Errorf("%w: max clicks must be at least 1", ErrInvalidExpiration)
+	}
+	return nil
+}
+
+// NormalizeTags valide des étiquettes de lien et les retourne en minuscules, sans doublon,
+// jointes par des virgules comme stockées dans models.Link.Tags.
+func NormalizeTags(tags []string) (string, error) {
+	seen := make(map[string]bool, len(tags))
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength || !tagPattern.MatchString(tag) {
+			return "", fmt.Errorf("%w: %q (letters, digits, '-' and '_', at most %d characters)", ErrInvalidTag, tag, maxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTags {
+		return "", fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidTag, maxTags)
+	}
+	joined := strings.Join(normalized, ",")
+	if len(joined) > maxTagsTotalSize {
+		return "", fmt.Errorf("%w: tags must total at most %d characters, separators included", ErrInvalidTag, maxTagsTotalSize)
+	}
+	return joined, nil
+}
+
+// ValidateAlias vérifie qu'un alias personnalisé respecte le format attendu
+// et qu'il ne fait pas partie des mots réservés.
+func ValidateAlias(alias string) error {
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength {
+		return fmt.Errorf("%w: length must be between %d and %d characters", ErrInvalidAlias, minAliasLength, maxAliasLength)
+	}
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: only letters, digits, '-' and '_' are allowed", ErrInvalidAlias)
+	}
+	if reservedAliases[strings.ToLower(alias)] {
+		return fmt.Errorf("%w: %q", ErrReservedAlias, alias)
+	}
+	return nil
+}
+
 // CreateLink crée un nouveau lien raccourci.
-// Il génère un code court unique, puis persiste le lien dans la base de données.
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
+// Si opts.Alias est renseigné, il est validé et utilisé comme code court ;
+// sinon un code court unique est généré. Le lien est ensuite persisté dans la base de données.
+func (s *LinkService) CreateLink(longURL string, opts LinkOptions) (*models.Link, error) {
+	if err := validateLifetime(opts); err != nil {
+		return nil, err
+	}
+	tags, err := NormalizeTags(opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkURL(longURL); err != nil {
+		return nil, err
+	}
+	if opts.FallbackURL != "" {
+		if err := s.checkURL(opts.FallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	link := models.Link{
+		LongURL:     longURL,
+		ExpiresAt:   opts.ExpiresAt,
+		MaxClicks:   opts.MaxClicks,
+		APIKeyID:    opts.APIKeyID,
+		Tags:        tags,
+		FallbackURL: opts.FallbackURL,
+	}
+
+	if opts.Alias != "" {
+		return s.createLinkWithAlias(&link, opts.Alias)
+	}
+
 	// TODO 1: Implémenter la logique de retry pour générer un code court unique.
 	// Essayez de générer un code, vérifiez s'il existe déjà en base, et retentez si une collision est trouvée.
 	// Limitez le nombre de tentatives pour éviter une boucle infinie.
-	var shortCode string
-	var err error
-
+	// Un code peut aussi appartenir à un lien supprimé (soft delete), invisible pour
+	// GetLinkByShortCode mais toujours soumis à l'index unique : l'insertion refusée
+	// (gorm.ErrDuplicatedKey) est alors traitée comme une collision.
 	maxRetries := 5
 	for i := 0; i < maxRetries; i++ {
+		link.ShortCode, err = s.GenerateShortCode()
+		if err != nil {
+			return nil, err
+		}
 
-		shortCode, err = s.GenerateShortCode()
-		_, err = s.linkRepo.GetLinkByShortCode(shortCode)
+		_, err = s.linkRepo.GetLinkByShortCode(link.ShortCode)
+		if err == nil {
+			// Si aucune erreur (le code a été trouvé), cela signifie une collision.
+			slog.Info("Code court déjà utilisé, nouvelle génération", "short_code", link.ShortCode, "attempt", i+1, "max_retries", maxRetries)
+			continue
+		}
+		// Si c'est une autre erreur que 'record not found', retourne l'erreur.
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
+		}
 
+		err = s.linkRepo.CreateLink(&link)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			slog.Info("Code court déjà utilisé par un lien supprimé, nouvelle génération", "short_code", link.ShortCode, "attempt", i+1, "max_retries", maxRetries)
+			continue
+		}
 		if err != nil {
-			// Si l'erreur est 'record not found' de GORM, cela signifie que le code est unique.
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				break
-			}
-			// Si c'est une autre erreur de base de données, retourne l'erreur.
-			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
-		} else {
-			if i == maxRetries-1 {
-				return nil, errors.New("maximum number of retries reached")
-			}
+			slog.Error("Erreur lors de la création du lien", "error", err)
+			return nil, err
 		}
+		return &link, nil
+	}
 
-		// Si aucune erreur (le code a été trouvé), cela signifie une collision.
-		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", shortCode, i+1, maxRetries)
-		// La boucle continuera pour générer un nouveau code.
+	return nil, errors.New("maximum number of retries reached")
+}
+
+// createLinkWithAlias persiste un lien dont le code court est choisi par l'utilisateur.
+// Il retourne ErrAliasTaken si l'alias est déjà utilisé.
+func (s *LinkService) createLinkWithAlias(link *models.Link, alias string) (*models.Link, error) {
+	if err := ValidateAlias(alias); err != nil {
+		return nil, err
 	}
 
-	link := models.Link{
-		ShortCode: shortCode,
-		LongURL:   longURL,
+	_, err := s.linkRepo.GetLinkByShortCode(alias)
+	if err == nil {
+		return nil, fmt.Errorf("%w: %q", ErrAliasTaken, alias)
 	}
-	err = s.linkRepo.CreateLink(&link)
-	if err != nil {
-		log.Printf("Error creating link: %v", err)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("database error checking alias availability: %w", err)
+	}
+
+	link.ShortCode = alias
+	if err := s.linkRepo.CreateLink(link); err != nil {
+		// Un autre lien a pu réserver le même alias entre la vérification et l'insertion.
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fmt.Errorf("%w: %q", ErrAliasTaken, alias)
+		}
+		slog.Error("Erreur lors de la création du lien", "error", err)
 		return nil, err
 	}
 
-	return &link, nil
+	return link, nil
 }
 
 // GetLinkByShortCode récupère un lien via son code court.
@@ -102,19 +272,121 @@ func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error)
 	return s.linkRepo.GetLinkByShortCode(shortCode)
 }
 
+// GetLink récupère un lien via son code court pour le consulter ou le modifier.
+// Si ownerKeyID est renseigné, un lien appartenant à une autre clé API est traité comme
+// introuvable (gorm.ErrRecordNotFound) afin de ne pas révéler son existence.
+func (s *LinkService) GetLink(shortCode string, ownerKeyID *uint) (*models.Link, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if ownerKeyID != nil && (link.APIKeyID == nil || *link.APIKeyID != *ownerKeyID) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return link, nil
+}
+
+// ListLinks retourne une page de liens correspondant au filtre ainsi que le nombre total de résultats.
+func (s *LinkService) ListLinks(filter repository.LinkFilter) ([]models.Link, int64, error) {
+	links, total, err := s.linkRepo.ListLinks(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing links: %w", err)
+	}
+	return links, total, nil
+}
+
+// UpdateLinkURL change l'URL de destination d'un lien existant.
+// Si ownerKeyID est renseigné, seul un lien créé par cette clé API peut être modifié.
+func (s *LinkService) UpdateLinkURL(shortCode, longURL string, ownerKeyID *uint) (*models.Link, error) {
+	if err := s.checkURL(longURL); err != nil {
+		return nil, err
+	}
+
+	link, err := s.GetLink(shortCode, ownerKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving link: %w", err)
+	}
+
+	link.LongURL = longURL
+	if err := s.linkRepo.UpdateLink(link); err != nil {
+		return nil, fmt.Errorf("error updating link: %w", err)
+	}
+	return link, nil
+}
+
+// DeleteLink supprime logiquement un lien : il n'est plus redirigé ni listé,
+// mais ses clics sont conservés et il peut être restauré avec RestoreLink.
+// Si ownerKeyID est renseigné, seul un lien créé par cette clé API peut être supprimé.
+func (s *LinkService) DeleteLink(shortCode string, ownerKeyID *uint) error {
+	link, err := s.GetLink(shortCode, ownerKeyID)
+	if err != nil {
+		return fmt.Errorf("error retrieving link: %w", err)
+	}
+
+	if err := s.linkRepo.DeleteLink(link); err != nil {
+		return fmt.Errorf("error deleting link: %w", err)
+	}
+	return nil
+}
+
+// RestoreLink restaure un lien précédemment supprimé.
+// Si ownerKeyID est renseigné, seul un lien créé par cette clé API peut être restauré.
+func (s *LinkService) RestoreLink(shortCode string, ownerKeyID *uint) (*models.Link, error) {
+	link, err := s.linkRepo.RestoreLink(shortCode, ownerKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("error restoring link: %w", err)
+	}
+	return link, nil
+}
+
+// ResolveLink récupère le lien à utiliser pour une redirection.
+// Il retourne le lien accompagné de ErrLinkExpired si sa date d'expiration est dépassée
+// ou si son budget de clics est épuisé.
+func (s *LinkService) ResolveLink(shortCode string) (*models.Link, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.HasExpired(time.Now()) {
+		return link, fmt.Errorf("%w: %q", ErrLinkExpired, shortCode)
+	}
+	return link, nil
+}
+
+// ReserveClick décompte une redirection servie à un visiteur du budget de clics du lien.
+// Le décompte est fait en base par une mise à jour conditionnelle : le budget est respecté
+// même avec plusieurs instances du serveur. Il retourne ErrLinkExpired si le budget est épuisé.
+// Les redirections de robots ne doivent pas être décomptées.
+func (s *LinkService) ReserveClick(link *models.Link) error {
+	if link.MaxClicks == nil {
+		return nil
+	}
+	ok, err := s.linkRepo.ReserveClick(link)
+	if err != nil {
+		return fmt.Errorf("error reserving click: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrLinkExpired, link.ShortCode)
+	}
+	return nil
+}
+
 // GetLinkStats récupère les statistiques pour un lien donné (nombre total de clics).
 // Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository
-func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
+// Les clics de robots sont exclus sauf si filter.IncludeBots est vrai.
+// Si ownerKeyID est renseigné, seules les statistiques d'un lien créé par cette clé API sont accessibles.
+func (s *LinkService) GetLinkStats(shortCode string, filter repository.ClickFilter, ownerKeyID *uint) (*models.Link, int, error) {
 	var err error
 	var link *models.Link
 	var count int
 
-	link, err = s.linkRepo.GetLinkByShortCode(shortCode)
+	link, err = s.GetLink(shortCode, ownerKeyID)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error retrieving link: %w", err)
 	}
 
-	count, err = s.linkRepo.CountClicksByLinkID(link.ID)
+	count, err = s.linkRepo.CountClicksByLinkID(link.ID, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error retrieving link stats: %w", err)
 	}
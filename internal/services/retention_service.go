@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"urlshortener/internal/repository"
+)
+
+// RetentionMode définit le sort des clics plus anciens que la durée de conservation.
+type RetentionMode string
+
+// Modes de rétention supportés.
+const (
+	// RetentionDelete supprime définitivement les clics expirés.
+	RetentionDelete RetentionMode = "delete"
+	// RetentionAggregate remplace les clics expirés par des compteurs journaliers par lien,
+	// ce qui préserve les totaux sans conserver d'IP ni de User-Agent.
+	RetentionAggregate RetentionMode = "aggregate"
+)
+
+// ParseRetentionMode convertit une chaîne en RetentionMode, en retournant ErrInvalidRetentionPolicy si elle n'est pas supportée.
+func ParseRetentionMode(value string) (RetentionMode, error) {
+	switch RetentionMode(value) {
+	case RetentionDelete, RetentionAggregate:
+		return RetentionMode(value), nil
+	}
+	return "", fmt.Errorf("%w: unknown mode %q (expected delete or aggregate)", ErrInvalidRetentionPolicy, value)
+}
+
+// RetentionPolicy décrit la durée de conservation des clics détaillés.
+type RetentionPolicy struct {
+	Days int           // Nombre de jours de conservation (0 = conservation illimitée)
+	Mode RetentionMode // Traitement des clics plus anciens
+}
+
+// PruneResult résume l'application (ou la simulation) de la politique de rétention.
+type PruneResult struct {
+	Cutoff time.Time     // Les clics antérieurs à cette date sont concernés
+	Mode   RetentionMode // Traitement appliqué
+	Clicks int64         // Nombre de clics supprimés ou agrégés (ou qui le seraient)
+	DryRun bool          // Vrai si aucune modification n'a été faite
+}
+
+// RetentionService applique la politique de rétention des clics.
+type RetentionService struct {
+	clickRepo repository.ClickRepository
+	policy    RetentionPolicy
+}
+
+// NewRetentionService crée un RetentionService après validation de la politique.
+func NewRetentionService(clickRepo repository.ClickRepository, policy RetentionPolicy) (*RetentionService, error) {
+	if policy.Days < 0 {
+		return nil, fmt.Errorf("%w: days must not be negative", ErrInvalidRetentionPolicy)
+	}
+	if _, err := ParseRetentionMode(string(policy.Mode)); err != nil {
+		return nil, err
+	}
+	return &RetentionService{clickRepo: clickRepo, policy: policy}, nil
+}
+
+// Enabled indique si une durée de conservation est configurée.
+func (s *RetentionService) Enabled() bool {
+	return s.policy.Days > 0
+}
+
+// Prune supprime ou agrège les clics plus anciens que la durée de conservation.
+// En mode dryRun, il se contente de compter les clics concernés.
+func (s *RetentionService) Prune(dryRun bool) (*PruneResult, error) {
+	if !s.Enabled() {
+		return nil, fmt.Errorf("%w: retention is disabled (0 days)", ErrInvalidRetentionPolicy)
+	}
+
+	result := &PruneResult{
+		Cutoff: time.Now().AddDate(0, 0, -s.policy.Days),
+		Mode:   s.policy.Mode,
+		DryRun: dryRun,
+	}
+
+	var err error
+	switch {
+	case dryRun:
+		result.Clicks, err = s.clickRepo.CountClicksBefore(result.Cutoff)
+	case s.policy.Mode == RetentionAggregate:
+		result.Clicks, err = s.clickRepo.AggregateClicksBefore(result.Cutoff)
+	default:
+		result.Clicks, err = s.clickRepo.DeleteClicksBefore(result.Cutoff)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error pruning clicks: %w", err)
+	}
+	return result, nil
+}
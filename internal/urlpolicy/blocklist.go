@@ -0,0 +1,139 @@
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"urlshortener/internal/services"
+)
+
+// Blocklist refuse les URLs dont l'hôte, ou l'un de ses domaines parents, figure dans un fichier
+// local (un domaine par ligne, '#' pour les commentaires). Le fichier est relu par Start dès qu'il
+// est modifié, sans redémarrer le serveur.
+type Blocklist struct {
+	path string
+
+	mu      sync.RWMutex
+	domains map[string]bool
+	modTime time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// LoadBlocklist lit le fichier de domaines bloqués 'path'.
+func LoadBlocklist(path string) (*Blocklist, error) {
+	b := &Blocklist{path: path}
+	if _, err := b.reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Len retourne le nombre de domaines bloqués.
+func (b *Blocklist) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.domains)
+}
+
+// CheckURL implémente services.URLPolicy.
+func (b *Blocklist) CheckURL(longURL string) error {
+	host, err := parseHost(longURL)
+	if err != nil {
+		return err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for domain := host; domain != ""; {
+		if b.domains[domain] {
+			return reject(services.ReasonDomainBlocked, "domain %q is blocked", domain)
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			break
+		}
+		domain = parent
+	}
+	return nil
+}
+
+// reload relit le fichier s'il a été modifié depuis la dernière lecture.
+// Il indique si la liste a été remplacée.
+func (b *Blocklist) reload() (bool, error) {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return false, fmt.Errorf("error reading domain blocklist: %w", err)
+	}
+	b.mu.RLock()
+	unchanged := info.ModTime().Equal(b.modTime) && b.domains != nil
+	b.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return false, fmt.Errorf("error reading domain blocklist: %w", err)
+	}
+	domains := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		line = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(line)), ".")
+		line = strings.TrimPrefix(line, "*.")
+		if line != "" {
+			domains[line] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.domains = domains
+	b.modTime = info.ModTime()
+	b.mu.Unlock()
+	return true, nil
+}
+
+// Start vérifie toutes les 'interval', dans une goroutine séparée, si le fichier a été modifié
+// et le recharge le cas échéant. En cas d'erreur de lecture, la liste précédente est conservée.
+func (b *Blocklist) Start(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	logger := slog.With("component", "url_policy")
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if reloaded, err := b.reload(); err != nil {
+					logger.Error("Erreur lors du rechargement de la liste de domaines bloqués", "file", b.path, "error", err)
+				} else if reloaded {
+					logger.Info("Liste de domaines bloqués rechargée", "file", b.path, "domains", b.Len())
+				}
+			}
+		}
+	}()
+}
+
+// Stop arrête le rechargement périodique du fichier.
+func (b *Blocklist) Stop() {
+	if b.cancel == nil {
+		return
+	}
+	b.cancel()
+	b.wg.Wait()
+}
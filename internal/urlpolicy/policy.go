@@ -0,0 +1,128 @@
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"urlshortener/internal/services"
+)
+
+// reject construit l'erreur de refus retournée par les politiques de ce package.
+func reject(reason, format string, args ...any) error {
+	return &services.URLRejectedError{Reason: reason, Detail: fmt.Sprintf(format, args...)}
+}
+
+// parseHost analyse une URL de destination et retourne son nom d'hôte, sans port ni point final.
+func parseHost(longURL string) (string, error) {
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return "", reject(services.ReasonInvalidURL, "%v", err)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return "", reject(services.ReasonInvalidURL, "missing host")
+	}
+	return host, nil
+}
+
+// SchemePolicy n'accepte que les URLs absolues dont le schéma figure dans la liste autorisée
+// (ex: http et https), ce qui écarte javascript:, data:, file:...
+type SchemePolicy struct {
+	allowed map[string]bool
+}
+
+// NewSchemePolicy crée une SchemePolicy autorisant les schémas donnés (insensibles à la casse).
+func NewSchemePolicy(schemes []string) *SchemePolicy {
+	allowed := make(map[string]bool, len(schemes))
+	for _, scheme := range schemes {
+		allowed[strings.ToLower(strings.TrimSpace(scheme))] = true
+	}
+	return &SchemePolicy{allowed: allowed}
+}
+
+// CheckURL implémente services.URLPolicy.
+func (p *SchemePolicy) CheckURL(longURL string) error {
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return reject(services.ReasonInvalidURL, "%v", err)
+	}
+	if scheme := strings.ToLower(u.Scheme); !p.allowed[scheme] {
+		return reject(services.ReasonSchemeNotAllowed, "scheme %q is not allowed", u.Scheme)
+	}
+	if u.Host == "" {
+		return reject(services.ReasonInvalidURL, "missing host")
+	}
+	return nil
+}
+
+// IsPublicAddr indique si une adresse IP est joignable publiquement. Sont exclues les adresses
+// de bouclage, privées, lien-local (dont les services de métadonnées cloud 169.254.169.254 et fd00:ec2::254),
+// non spécifiées, multicast et le partage d'adresses des opérateurs (100.64.0.0/10).
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	switch {
+	case !addr.IsValid(),
+		addr.IsLoopback(),
+		addr.IsPrivate(),
+		addr.IsLinkLocalUnicast(),
+		addr.IsLinkLocalMulticast(),
+		addr.IsInterfaceLocalMulticast(),
+		addr.IsMulticast(),
+		addr.IsUnspecified(),
+		sharedAddressSpace.Contains(addr):
+		return false
+	}
+	return true
+}
+
+// sharedAddressSpace est la plage réservée au NAT des opérateurs (RFC 6598).
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// AddressPolicy résout le nom d'hôte d'une URL et la refuse si l'une de ses adresses n'est pas
+// publique (voir IsPublicAddr), afin que les liens ne puissent pas cibler le réseau interne.
+// Un hôte qui ne se résout pas est également refusé.
+type AddressPolicy struct {
+	resolver *net.Resolver
+	timeout  time.Duration
+}
+
+// NewAddressPolicy crée une AddressPolicy dont chaque résolution DNS est limitée à 'timeout'.
+func NewAddressPolicy(timeout time.Duration) *AddressPolicy {
+	return &AddressPolicy{resolver: net.DefaultResolver, timeout: timeout}
+}
+
+// CheckURL implémente services.URLPolicy.
+func (p *AddressPolicy) CheckURL(longURL string) error {
+	host, err := parseHost(longURL)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return reject(services.ReasonPrivateAddress, "host %q is local", host)
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !IsPublicAddr(addr) {
+			return reject(services.ReasonPrivateAddress, "address %s is not public", addr)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	addrs, err := p.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return reject(services.ReasonUnresolvableHost, "host %q cannot be resolved", host)
+	}
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return reject(services.ReasonPrivateAddress, "host %q resolves to non-public address %s", host, addr.Unmap())
+		}
+	}
+	return nil
+}
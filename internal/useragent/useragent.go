@@ -0,0 +1,105 @@
+package useragent
+
+import "strings"
+
+// Classes d'appareils reconnues.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceOther   = "other"
+)
+
+// Unknown est la valeur utilisée quand un élément du User-Agent n'est pas reconnu.
+const Unknown = "Other"
+
+// Info regroupe les informations extraites d'un en-tête User-Agent.
+type Info struct {
+	Browser string // Famille de navigateur (ex: "Chrome", "Firefox")
+	OS      string // Système d'exploitation (ex: "Windows", "iOS")
+	Device  string // Classe d'appareil : desktop, mobile, tablet ou other
+}
+
+// rule associe un motif recherché dans le User-Agent à un nom.
+type rule struct {
+	token string
+	name  string
+}
+
+// browserRules est parcourue dans l'ordre : les navigateurs basés sur Chromium
+// ou WebKit doivent être testés avant Chrome et Safari, dont ils reprennent les jetons.
+var browserRules = []rule{
+	{"Edg", "Edge"},
+	{"OPR/", "Opera"},
+	{"Opera", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"YaBrowser/", "Yandex"},
+	{"Vivaldi/", "Vivaldi"},
+	{"CriOS/", "Chrome"},
+	{"FxiOS/", "Firefox"},
+	{"Firefox/", "Firefox"},
+	{"Chromium/", "Chromium"},
+	{"Chrome/", "Chrome"},
+	{"MSIE ", "Internet Explorer"},
+	{"Trident/", "Internet Explorer"},
+	{"Version/", "Safari"}, // Safari n'est identifiable que par son jeton Version/ suivi de Safari/
+	{"curl/", "curl"},
+	{"Wget/", "Wget"},
+	{"python-requests/", "Python Requests"},
+	{"Go-http-client/", "Go HTTP client"},
+}
+
+// osRules est parcourue dans l'ordre : iOS et Android doivent être testés avant
+// macOS et Linux, dont ils reprennent les jetons.
+var osRules = []rule{
+	{"Windows Phone", "Windows Phone"},
+	{"Windows", "Windows"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"iPod", "iOS"},
+	{"Android", "Android"},
+	{"CrOS", "Chrome OS"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// Parse analyse un en-tête User-Agent et en extrait le navigateur, le système
+// d'exploitation et la classe d'appareil. L'analyse repose sur des jetons connus
+// et ne cherche pas à identifier les versions.
+func Parse(ua string) Info {
+	info := Info{
+		Browser: matchRule(ua, browserRules),
+		OS:      matchRule(ua, osRules),
+	}
+	if info.Browser == "Safari" && !strings.Contains(ua, "Safari/") {
+		info.Browser = Unknown
+	}
+	info.Device = deviceClass(ua, info.OS)
+	return info
+}
+
+// matchRule retourne le nom de la première règle dont le jeton figure dans ua.
+func matchRule(ua string, rules []rule) string {
+	for _, r := range rules {
+		if strings.Contains(ua, r.token) {
+			return r.name
+		}
+	}
+	return Unknown
+}
+
+// deviceClass détermine la classe d'appareil à partir du User-Agent et du système détecté.
+func deviceClass(ua, os string) string {
+	switch {
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet"):
+		return DeviceTablet
+	case os == "Android" && !strings.Contains(ua, "Mobile"):
+		return DeviceTablet
+	case strings.Contains(ua, "Mobi") || os == "iOS" || os == "Android" || os == "Windows Phone":
+		return DeviceMobile
+	case os == "Windows" || os == "macOS" || os == "Linux" || os == "Chrome OS":
+		return DeviceDesktop
+	}
+	return DeviceOther
+}
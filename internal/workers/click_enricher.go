@@ -0,0 +1,91 @@
+package workers
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"urlshortener/internal/bots"
+	"urlshortener/internal/models"
+	"urlshortener/internal/privacy"
+	"urlshortener/internal/useragent"
+)
+
+// Tailles des colonnes texte de clicks (voir models.Click), appliquées avant l'insertion :
+// une valeur trop longue ferait échouer tout le lot sur Postgres et MySQL.
+const (
+	maxUserAgentLength    = 255
+	maxReferrerLength     = 512
+	maxReferrerHostLength = 255
+)
+
+// ClickEnricher transforme les événements de clic bruts en enregistrements à persister :
+// interprétation du User-Agent, extraction du domaine du Referer, détection des robots,
+// calcul de l'empreinte anonyme du visiteur et anonymisation de l'IP.
+type ClickEnricher struct {
+	BotClassifier      *bots.Classifier       // Marque les clics provenant de robots
+	VisitorHasher      *privacy.VisitorHasher // Calcule l'empreinte journalière du visiteur (optionnel)
+	DropRawIdentifiers bool                   // Ne conserve ni l'IP ni le User-Agent bruts, seulement l'empreinte
+	AnonymizeIP        bool                   // Tronque l'IP (/24 en IPv4, /48 en IPv6) avant l'enregistrement
+}
+
+// NewClick construit l'enregistrement à persister à partir d'un événement brut.
+func (e *ClickEnricher) NewClick(event models.ClickEvent) *models.Click {
+	ua := useragent.Parse(event.UserAgent)
+
+	click := &models.Click{
+		LinkID:       event.LinkID,
+		Timestamp:    event.Timestamp,
+		UserAgent:    truncate(event.UserAgent, maxUserAgentLength),
+		IPAddress:    event.IPAddress,
+		Referrer:     truncate(event.Referrer, maxReferrerLength),
+		ReferrerHost: truncate(referrerHost(event.Referrer), maxReferrerHostLength),
+		Browser:      ua.Browser,
+		OS:           ua.OS,
+		Device:       ua.Device,
+		Fallback:     event.Fallback,
+		RequestID:    event.RequestID,
+	}
+	if e.BotClassifier != nil {
+		click.IsBot = e.BotClassifier.IsBot(event)
+	}
+	// L'empreinte est calculée sur l'IP complète, qui n'est ensuite jamais persistée telle quelle
+	// si l'anonymisation est active.
+	if e.VisitorHasher != nil {
+		click.VisitorHash = e.VisitorHasher.Hash(event.IPAddress, event.UserAgent, event.Timestamp)
+		if e.DropRawIdentifiers {
+			click.IPAddress = ""
+			click.UserAgent = ""
+		}
+	}
+	if e.AnonymizeIP && click.IPAddress != "" {
+		click.IPAddress = privacy.AnonymizeIP(click.IPAddress)
+	}
+	return click
+}
+
+// referrerHost extrait le nom d'hôte (en minuscules) d'un en-tête Referer.
+// Il retourne une chaîne vide si le Referer est absent ou invalide.
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// truncate limite s à maxLen octets sans couper de caractère UTF-8. Les octets invalides sont
+// remplacés, Postgres refusant les chaînes qui ne sont pas en UTF-8 valide.
+func truncate(s string, maxLen int) string {
+	if len(s) > maxLen {
+		cut := maxLen
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut]
+	}
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
@@ -1,44 +1,230 @@
 package workers
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"urlshortener/internal/metrics"
 	"urlshortener/internal/models"
 	"urlshortener/internal/repository" // Nécessaire pour interagir avec le ClickRepository
 )
 
-// StartClickWorkers lance un pool de goroutines "workers" pour traiter les événements de clic.
-// Chaque worker lira depuis le même 'clickEventsChan' et utilisera le 'clickRepo' pour la persistance.
-func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	log.Printf("Starting %d click worker(s)...", workerCount)
-	for i := 0; i < workerCount; i++ {
-		// Lance chaque worker dans sa propre goroutine.
-		// Le channel est passé en lecture seule (<-chan) pour renforcer l'immutabilité du channel à l'intérieur du worker.
-		go clickWorker(clickEventsChan, clickRepo)
+// BatchOptions définit quand un worker écrit en base les clics qu'il a accumulés :
+// dès que Size clics sont en attente, ou au plus tard FlushInterval après le dernier envoi.
+type BatchOptions struct {
+	Size          int
+	FlushInterval time.Duration
+	// OnPersisted, si renseignée, est appelée avec les événements d'un lot après son écriture réussie
+	// (acquittement de la file durable). Un lot en échec n'est pas acquitté.
+	OnPersisted func(events []models.ClickEvent)
+}
+
+// DrainResult résume la vidange des workers lors de l'arrêt.
+type DrainResult struct {
+	Pending  int64 // Événements en attente (channel et tampons des workers) au début de l'arrêt
+	Drained  int64 // Événements persistés pendant l'arrêt
+	Lost     int64 // Sans file durable : événements non persistés avant l'échéance ou dont l'écriture a échoué
+	Deferred int64 // Événements non persistés mais conservés par la file durable, rejoués au prochain démarrage
+}
+
+// ClickWorkerPool est un pool de goroutines "workers" qui traitent les événements de clic.
+// Chaque worker lit depuis le même channel, accumule les clics dans un tampon
+// et les persiste par lots (une seule insertion multi-lignes par lot).
+type ClickWorkerPool struct {
+	workerCount     int
+	batch           BatchOptions
+	clickEventsChan chan models.ClickEvent
+	clickRepo       repository.ClickRepository
+	enricher        *ClickEnricher // Prépare chaque clic (User-Agent, robots, empreinte du visiteur)
+	logger          *slog.Logger   // Journal des workers (component=click_workers)
+
+	stop      chan struct{} // Fermé par Stop : les workers vident le channel puis s'arrêtent
+	abandon   chan struct{} // Fermé à l'échéance de Stop : les workers s'arrêtent après le lot en cours
+	wg        sync.WaitGroup
+	buffered  atomic.Int64 // Événements lus dans le channel et pas encore écrits
+	persisted atomic.Int64 // Événements écrits avec succès depuis le démarrage
+}
+
+// NewClickWorkerPool crée un pool de 'workerCount' workers lisant 'clickEventsChan'.
+// Le channel n'est jamais fermé : une redirection encore en cours après l'arrêt peut y écrire sans
+// paniquer, son événement est simplement perdu.
+func NewClickWorkerPool(workerCount int, batch BatchOptions, clickEventsChan chan models.ClickEvent, clickRepo repository.ClickRepository, enricher *ClickEnricher) *ClickWorkerPool {
+	if batch.Size < 1 {
+		batch.Size = 1
+	}
+	return &ClickWorkerPool{
+		workerCount:     workerCount,
+		batch:           batch,
+		clickEventsChan: clickEventsChan,
+		clickRepo:       clickRepo,
+		enricher:        enricher,
+		logger:          slog.With("component", "click_workers"),
+		stop:            make(chan struct{}),
+		abandon:         make(chan struct{}),
+	}
+}
+
+// Start lance les workers, chacun dans sa propre goroutine.
+func (p *ClickWorkerPool) Start() {
+	p.logger.Info("Démarrage des workers de clics",
+		"workers", p.workerCount, "batch_size", p.batch.Size, "flush_interval", p.batch.FlushInterval.String())
+	p.wg.Add(p.workerCount)
+	for i := 0; i < p.workerCount; i++ {
+		go p.clickWorker()
 	}
 }
 
+// Stop demande aux workers d'écrire les événements restants puis de s'arrêter, et attend leur fin
+// au plus tard jusqu'à l'échéance de ctx. Passé ce délai, les workers abandonnent les événements
+// non lus et s'arrêtent après leur lot en cours : voir Wait.
+func (p *ClickWorkerPool) Stop(ctx context.Context) DrainResult {
+	pending := int64(len(p.clickEventsChan)) + p.buffered.Load()
+	persistedBefore := p.persisted.Load()
+	close(p.stop)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		close(p.abandon)
+		p.logger.Warn("Les workers de clics n'ont pas terminé avant l'échéance de l'arrêt", "error", ctx.Err())
+	}
+
+	drained := p.persisted.Load() - persistedBefore
+	result := DrainResult{Pending: pending, Drained: drained}
+	// Avec une file durable (OnPersisted), un événement non acquitté reste sur disque : il n'est pas perdu.
+	if p.batch.OnPersisted != nil {
+		result.Deferred = pending - drained
+	} else {
+		result.Lost = pending - drained
+	}
+	return result
+}
+
+// Wait attend la fin des workers arrêtés par Stop, y compris d'un lot en cours d'écriture après
+// l'échéance. La base de données ne doit être fermée qu'ensuite.
+func (p *ClickWorkerPool) Wait() {
+	p.wg.Wait()
+}
+
+// clickBatch est le tampon d'un worker : les clics à insérer et les événements dont ils proviennent.
+type clickBatch struct {
+	clicks []*models.Click
+	events []models.ClickEvent
+}
+
 // clickWorker est la fonction exécutée par chaque goroutine worker.
-// Elle tourne indéfiniment, lisant les événements de clic dès qu'ils sont disponibles dans le channel.
-func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	for event := range clickEventsChan { // Boucle qui lit les événements du channel
-		click := &models.Click{
-			LinkID:    event.LinkID,
-			Timestamp: event.Timestamp,
-			UserAgent: event.UserAgent,
-			IPAddress: event.IPAddress,
+// Elle lit les événements de clic dès qu'ils sont disponibles dans le channel et vide son tampon
+// lorsque le seuil de taille est atteint ou à chaque échéance du seuil de temps.
+// À l'arrêt, les événements encore dans le channel sont écrits avant de terminer (voir drain).
+func (p *ClickWorkerPool) clickWorker() {
+	defer p.wg.Done()
+
+	batch := &clickBatch{
+		clicks: make([]*models.Click, 0, p.batch.Size),
+		events: make([]models.ClickEvent, 0, p.batch.Size),
+	}
+
+	// Sans seuil de temps, le ticker n'est jamais créé et seul le seuil de taille déclenche l'écriture.
+	var tick <-chan time.Time
+	if p.batch.FlushInterval > 0 {
+		ticker := time.NewTicker(p.batch.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case event := <-p.clickEventsChan:
+			p.add(batch, event)
+		case <-tick:
+			p.flush(batch)
+		case <-p.stop:
+			p.drain(batch)
+			return
+		}
+	}
+}
+
+// add ajoute un événement au tampon et l'écrit si le seuil de taille est atteint.
+func (p *ClickWorkerPool) add(batch *clickBatch, event models.ClickEvent) {
+	batch.clicks = append(batch.clicks, p.enricher.NewClick(event))
+	batch.events = append(batch.events, event)
+	p.buffered.Add(1)
+	if len(batch.clicks) >= p.batch.Size {
+		p.flush(batch)
+	}
+}
+
+// drain écrit les événements restant dans le channel, jusqu'à ce qu'il soit vide ou que l'échéance
+// de Stop soit dépassée, puis le tampon du worker.
+func (p *ClickWorkerPool) drain(batch *clickBatch) {
+	for {
+		select {
+		case <-p.abandon:
+			p.flush(batch)
+			return
+		default:
+		}
+		select {
+		case event := <-p.clickEventsChan:
+			p.add(batch, event)
+		default:
+			p.flush(batch)
+			return
 		}
-		err := clickRepo.CreateClick(click)
-		if err != nil {
-			// Si une erreur se produit lors de l'enregistrement, logguez-la.
-			// L'événement est "perdu" pour ce TP, mais dans un vrai système,
-			// vous pourriez le remettre dans une file de retry ou une file d'erreurs.
-			log.Printf("ERROR: Failed to save click for LinkID %d (UserAgent: %s, IP: %s): %v",
-				event.LinkID, event.UserAgent, event.IPAddress, err)
-
-		} else {
-			// Log optionnel pour confirmer l'enregistrement (utile pour le débogage)
-			log.Printf("Click recorded successfully for LinkID %d", event.LinkID)
+	}
+}
+
+// flush persiste les clics du tampon en une seule insertion puis vide le tampon.
+func (p *ClickWorkerPool) flush(batch *clickBatch) {
+	count := len(batch.clicks)
+	if count == 0 {
+		return
+	}
+
+	started := time.Now()
+	err := p.clickRepo.CreateClicks(batch.clicks)
+	metrics.ObserveSince(metrics.ClickInsertDuration, started)
+	metrics.ClickBatchSize.Observe(float64(count))
+	if err != nil {
+		metrics.ClickInsertErrors.Inc()
+		// Si une erreur se produit lors de l'enregistrement, logguez-la.
+		// Sans file durable, le lot est perdu ; avec, il reste sur disque et sera rejoué au prochain démarrage.
+		// Les identifiants de requête permettent de retrouver les redirections concernées.
+		p.logger.Error("Erreur lors de l'enregistrement d'un lot de clics",
+			"clicks", count, "request_ids", requestIDs(batch.events), "error", err)
+	} else {
+		// Log optionnel pour confirmer l'enregistrement (utile pour le débogage)
+		if p.logger.Enabled(context.Background(), slog.LevelDebug) {
+			p.logger.Debug("Lot de clics enregistré", "clicks", count, "request_ids", requestIDs(batch.events))
+		}
+		p.persisted.Add(int64(count))
+		if p.batch.OnPersisted != nil {
+			p.batch.OnPersisted(batch.events)
+		}
+	}
+	p.buffered.Add(-int64(count))
+
+	clear(batch.clicks)
+	batch.clicks = batch.clicks[:0]
+	batch.events = batch.events[:0]
+}
+
+// requestIDs retourne les identifiants de requête des événements, pour les journaux des lots.
+func requestIDs(events []models.ClickEvent) []string {
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		if event.RequestID != "" {
+			ids = append(ids, event.RequestID)
 		}
 	}
+	return ids
 }
@@ -0,0 +1,77 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"urlshortener/internal/services"
+)
+
+// RetentionJob applique périodiquement la politique de rétention des clics.
+type RetentionJob struct {
+	retentionService *services.RetentionService
+	interval         time.Duration
+	logger           *slog.Logger // Journal de la tâche (component=retention)
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRetentionJob crée une tâche de purge exécutée toutes les 'interval'.
+func NewRetentionJob(retentionService *services.RetentionService, interval time.Duration) *RetentionJob {
+	return &RetentionJob{
+		retentionService: retentionService,
+		interval:         interval,
+		logger:           slog.With("component", "retention"),
+	}
+}
+
+// Start lance la tâche dans une goroutine séparée. Une première passe est exécutée immédiatement.
+func (j *RetentionJob) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+
+	j.wg.Add(1)
+	go j.run(ctx)
+}
+
+// Stop arrête la tâche et attend la fin de la passe en cours.
+func (j *RetentionJob) Stop() {
+	if j.cancel == nil {
+		return
+	}
+	j.cancel()
+	j.wg.Wait()
+}
+
+// run est la boucle de la tâche, exécutée jusqu'à l'annulation de ctx.
+func (j *RetentionJob) run(ctx context.Context) {
+	defer j.wg.Done()
+
+	j.logger.Info("Démarrage de la purge des clics", "interval", j.interval.String())
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.runOnce()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.runOnce()
+		}
+	}
+}
+
+// runOnce exécute une passe de la politique de rétention et journalise son résultat.
+func (j *RetentionJob) runOnce() {
+	result, err := j.retentionService.Prune(false)
+	if err != nil {
+		j.logger.Error("Erreur lors de la purge des clics", "error", err)
+		return
+	}
+	j.logger.Info("Purge des clics terminée",
+		"clicks", result.Clicks, "cutoff", result.Cutoff.Format(time.DateTime), "mode", result.Mode)
+}